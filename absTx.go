@@ -3,17 +3,23 @@ package FabricTransaction
 import (
 	"encoding/json"
 	"errors"
+	"time"
 
-	"github.com/FabricTransaction/asset"
 	"github.com/FabricTransaction/assetPool"
 	"github.com/FabricTransaction/common"
 	"github.com/FabricTransaction/common/securityTool"
+	"github.com/FabricTransaction/orgManage"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	pb "github.com/hyperledger/fabric/protos/peer"
 )
 
 type SignStruct struct {
-	Sign string `json:"sign"`
+	Function   string `json:"function"`   //请求提交的链码函数名，须与实际调用的函数一致
+	Nonce      uint64 `json:"nonce"`      //签名资产池的请求序号，须大于该资产池已使用的序号
+	ExpireTime string `json:"expireTime"` //请求过期时间，RFC3339格式
+	//transient中各字段值的SHA-256摘要（base64），防止背书节点或中转方替换未签名的transient数据
+	TransientHashes map[string]string `json:"transientHashes,omitempty"`
+	Sign            string            `json:"sign"`
 }
 
 type AssetPoolReq struct {
@@ -22,97 +28,83 @@ type AssetPoolReq struct {
 }
 
 type TransferReq struct {
-	ToPool   string  `json:"toPool"`   //转入资产池ID
-	FromPool string  `json:"fromPool"` //转出资产池ID
-	Amount   float64 `json:"amount"`   //转让量
-	TxType   string  `json:"txType"`   //交易类型：发行/转让
-	asset.AssetInfo
-	SignStruct
-}
-
-type SignVerifyStruct struct {
-	AssetPoolID string `json:"assetPoolId"`
+	ToPool      string        `json:"toPool"`      //转入资产池ID
+	FromPool    string        `json:"fromPool"`    //转出资产池ID
+	Amount      common.Amount `json:"amount"`      //转让量
+	TxType      string        `json:"txType"`      //交易类型：发行/转让
+	AssetTypeID string        `json:"assetTypeId"` //资产类型
 	SignStruct
 }
 
+//供外部链码转发调用，args[0]为函数名，其余为函数参数
 func AbsTxInvoke(stub shim.ChaincodeStubInterface) pb.Response {
 	_, args := stub.GetFunctionAndParameters()
 	if len(args) < 1 {
 		return shim.Error("no abstx invoke function")
 	}
-	switch args[0] {
-	case "issue", "transfer":
-		err := VerifyReq(stub, args[1])
-		if err != nil {
-			return shim.Error(err.Error())
-		}
-
-		tx := TransferReq{}
-		err = json.Unmarshal([]byte(args[1]), &tx)
-		if err != nil {
-			return shim.Error(err.Error())
-		}
-		err = doAbsTx(stub, tx)
-		if err != nil {
-			return shim.Error(err.Error())
-		}
-		return shim.Success(nil)
-	case "addAssetPool":
-		pool := AssetPoolReq{}
-		err := json.Unmarshal([]byte(args[1]), &pool)
-		if err != nil {
-			return shim.Error(err.Error())
-		}
-		err = AddAssetPool(stub, pool)
-		if err != nil {
-			return shim.Error(err.Error())
-		}
-		return shim.Success(nil)
-	}
-
-	return shim.Success(nil)
+	return dispatch(stub, args[0], args[1:])
 }
 
-func doAbsTx(stub shim.ChaincodeStubInterface, tx TransferReq) error {
+//转账时返回消耗的输入
+func doAbsTx(stub shim.ChaincodeStubInterface, tx TransferReq) ([]byte, error) {
 	if tx.TxType == common.TX_TYPE_TRANSFER {
 		var from, to assetPool.AssetPool
 		if err := common.GetDataByKey(stub, common.OBJECT_TYPE_ASEETPOOL, []string{tx.FromPool}, &from); err != nil {
-			return err
+			return nil, err
 		}
 		if err := common.GetDataByKey(stub, common.OBJECT_TYPE_ASEETPOOL, []string{tx.ToPool}, &to); err != nil {
-			return err
+			return nil, err
 		}
-		ok, err := from.Transfer(stub, tx.AssetTypeID, to, tx.Amount)
-		if err != nil {
-			return err
+		if err := from.CheckOwner(stub); err != nil {
+			return nil, err
 		}
-		if !ok {
-			return errors.New("transfer failed")
+		if err := to.CheckRecipient(); err != nil {
+			return nil, err
+		}
+		selection, err := from.TransferWithSelection(stub, tx.AssetTypeID, to, tx.Amount)
+		if err != nil {
+			return nil, err
 		}
+		return json.Marshal(selection)
 	} else if tx.TxType == common.TX_TYPE_ISSUE {
 		var issuePool assetPool.AssetPool
 		if err := common.GetDataByKey(stub, common.OBJECT_TYPE_ASEETPOOL, []string{tx.ToPool}, &issuePool); err != nil {
-			return err
+			return nil, err
+		}
+		if err := issuePool.CheckOwner(stub); err != nil {
+			return nil, err
 		}
-		return issuePool.Issue(stub, tx.Amount, tx.AssetInfo)
+		return nil, issuePool.Issue(stub, tx.AssetTypeID, tx.Amount)
 	}
-	return errors.New("Invalid tx Type")
+	return nil, errors.New("Invalid tx Type")
 }
 
-func VerifyReq(stub shim.ChaincodeStubInterface, reqStr string) error {
-	verifyStruct := SignVerifyStruct{}
-	err := json.Unmarshal([]byte(reqStr), &verifyStruct)
+//verify reqStr was signed by the pool whose ID is in field signerField
+func VerifyReq(stub shim.ChaincodeStubInterface, reqStr string, signerField string) error {
+	fields := map[string]interface{}{}
+	err := json.Unmarshal([]byte(reqStr), &fields)
 	if err != nil {
 		return err
 	}
+	poolID, ok := fields[signerField].(string)
+	if !ok || common.IsEmptyStr(poolID) {
+		return errors.New("no signer pool in field " + signerField)
+	}
 
 	pool := assetPool.AssetPool{}
-	err = common.GetDataByKey(stub, common.OBJECT_TYPE_ASEETPOOL, []string{verifyStruct.AssetPoolID}, &pool)
+	err = common.GetDataByKey(stub, common.OBJECT_TYPE_ASEETPOOL, []string{poolID}, &pool)
 	if err != nil {
 		return err
 	}
+	if err = pool.CheckOwner(stub); err != nil {
+		return err
+	}
 
-	valid, err := securityTool.CheckJSONObjectSignatureString(reqStr, pool.PublicKey)
+	tool, err := pool.GetSecurityTool()
+	if err != nil {
+		return err
+	}
+	valid, err := securityTool.CheckJSONObjectSignatureString(tool, reqStr, pool.PublicKey)
 	if err != nil {
 		return err
 	}
@@ -120,10 +112,156 @@ func VerifyReq(stub shim.ChaincodeStubInterface, reqStr string) error {
 		return errors.New("verify sign failed")
 	}
 
+	sign := SignStruct{}
+	if err = json.Unmarshal([]byte(reqStr), &sign); err != nil {
+		return err
+	}
+	if err = checkExpireTime(stub, sign.ExpireTime); err != nil {
+		return err
+	}
+	if err = checkTransientHashes(stub, sign.TransientHashes); err != nil {
+		return err
+	}
+	return pool.UseNonce(stub, sign.Nonce)
+}
+
+//多方签名请求，每个签名资产池使用各自的请求序号
+type MultiSignStruct struct {
+	Function        string            `json:"function"`
+	Nonces          map[string]uint64 `json:"nonces"`     //签名资产池ID到请求序号
+	ExpireTime      string            `json:"expireTime"` //请求过期时间，RFC3339格式
+	TransientHashes map[string]string `json:"transientHashes,omitempty"`
+	Signs           map[string]string `json:"signs"` //签名资产池ID到签名，签名内容为去掉signs后的规范化JSON
+}
+
+//verify reqStr was signed by every pool in poolIDs; the invoker must own at least one of them
+func VerifyMultiSignedReq(stub shim.ChaincodeStubInterface, reqStr string, poolIDs []string) error {
+	req := MultiSignStruct{}
+	if err := json.Unmarshal([]byte(reqStr), &req); err != nil {
+		return err
+	}
+	canonical, err := securityTool.CanonicalizeJSON([]byte(reqStr), securityTool.MULTI_SIGN_FIELD)
+	if err != nil {
+		return err
+	}
+	invoker, err := orgManage.GetInvokerOrg(stub)
+	if err != nil {
+		return err
+	}
+	mspID := invoker.MspID
+
+	var pools []assetPool.AssetPool
+	invokerIsParty := false
+	for _, v := range poolIDs {
+		pool := assetPool.AssetPool{}
+		if err := common.GetDataByKey(stub, common.OBJECT_TYPE_ASEETPOOL, []string{v}, &pool); err != nil {
+			return errors.New("get asset pool " + v + " failed: " + err.Error())
+		}
+		sign, ok := req.Signs[v]
+		if !ok || common.IsEmptyStr(sign) {
+			return errors.New("no sign of asset pool " + v)
+		}
+		tool, err := pool.GetSecurityTool()
+		if err != nil {
+			return err
+		}
+		valid, err := tool.VerifySignByPoolPublicKey(canonical, sign, pool.PublicKey)
+		if err != nil {
+			return errors.New("verify sign of asset pool " + v + " failed: " + err.Error())
+		}
+		if !valid {
+			return errors.New("verify sign of asset pool " + v + " failed")
+		}
+		if pool.OwnerMspID == mspID {
+			invokerIsParty = true
+		}
+		pools = append(pools, pool)
+	}
+	if !invokerIsParty {
+		return errors.New("invoker " + mspID + " owns none of the signing asset pools")
+	}
+
+	if err = checkExpireTime(stub, req.ExpireTime); err != nil {
+		return err
+	}
+	if err = checkTransientHashes(stub, req.TransientHashes); err != nil {
+		return err
+	}
+	for _, v := range pools {
+		if err := v.UseNonce(stub, req.Nonces[v.AssetPoolAddr]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//签名请求只能提交给签名时指定的函数，防止对一个函数签名的请求被提交到参数结构相近的另一个函数
+func checkSignedFunction(reqStr string, fn string) error {
+	req := struct {
+		Function string `json:"function"`
+	}{}
+	if err := json.Unmarshal([]byte(reqStr), &req); err != nil {
+		return err
+	}
+	if req.Function != fn {
+		return errors.New("request is signed for function " + req.Function + ", not " + fn)
+	}
+	return nil
+}
+
+//transient中每个字段都须在签名请求中有对应摘要，签名请求中的每个摘要也须有对应字段
+func checkTransientHashes(stub shim.ChaincodeStubInterface, hashes map[string]string) error {
+	priData, err := stub.GetTransient()
+	if err != nil {
+		return err
+	}
+	for k, v := range priData {
+		hash, ok := hashes[k]
+		if !ok {
+			return errors.New("transient field " + k + " is not signed")
+		}
+		actual, err := securityTool.CalcSHA256Base64Str(string(v))
+		if err != nil {
+			return err
+		}
+		if actual != hash {
+			return errors.New("transient field " + k + " does not match signed hash")
+		}
+	}
+	for k := range hashes {
+		if _, ok := priData[k]; !ok {
+			return errors.New("signed transient field " + k + " is missing")
+		}
+	}
+	return nil
+}
+
+func checkExpireTime(stub shim.ChaincodeStubInterface, expireTime string) error {
+	if common.IsEmptyStr(expireTime) {
+		return errors.New("no expireTime in request")
+	}
+	expire, err := time.Parse(time.RFC3339, expireTime)
+	if err != nil {
+		return errors.New("invalid expireTime: " + err.Error())
+	}
+	txTime, err := common.GetTxTime(stub)
+	if err != nil {
+		return err
+	}
+	if txTime.After(expire) {
+		return errors.New("request expired at " + expireTime)
+	}
 	return nil
 }
 
 func AddAssetPool(stub shim.ChaincodeStubInterface, pool AssetPoolReq) error {
+	if pool.AssetPoolType == common.ASSET_POOL_TYPE_CONTRACT {
+		return errors.New("contract asset pool can only be created by chaincode")
+	}
+	org, err := orgManage.GetInvokerOrg(stub)
+	if err != nil {
+		return err
+	}
 	p := new(assetPool.AssetPool)
-	return p.Init(stub, pool.AssetPoolAddr, pool.PublicKey, pool.AssetPoolType)
+	return p.Init(stub, pool.AssetPoolAddr, pool.PublicKey, pool.KeyAlgorithm, pool.AssetPoolType, org.MspID)
 }
@@ -0,0 +1,119 @@
+package FabricTransaction
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FabricTransaction/client"
+	"github.com/FabricTransaction/common"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+//按给定的请求序号、过期时间和transient摘要签名转账请求
+func (pool *testPool) signTransfer(t *testing.T, to *testPool, nonce uint64, expireTime string, hashes map[string]string) string {
+	req := &TransferReq{FromPool: pool.addr, ToPool: to.addr, Amount: common.NewAmountFromInt(10), TxType: common.TX_TYPE_TRANSFER, AssetTypeID: "CNY"}
+	req.Function = "transfer"
+	req.Nonce = nonce
+	req.ExpireTime = expireTime
+	req.TransientHashes = hashes
+	signed, err := client.SignRequest(pool.key, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+//替换已签名请求中的金额写法后重新签名
+func (pool *testPool) resignAmount(t *testing.T, signed string, amount string) string {
+	var req map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(signed), &req); err != nil {
+		t.Fatal(err)
+	}
+	req["amount"] = json.RawMessage(amount)
+	resigned, err := client.SignRequest(pool.key, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resigned
+}
+
+func TestVerifyReqRejected(t *testing.T) {
+	stub := newTestStub(t)
+	poolA := stub.addPool(t, "Org1MSP", "poolA")
+	poolB := stub.addPool(t, "Org2MSP", "poolB")
+	stub.issue(t, poolA, "CNY", "100", "a1")
+	txTime := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	stub.txTime = txTime.Unix()
+
+	transient := stub.transferTransient(t, poolA, []string{"a1"}, "b1", "a2")
+	addEncryptSeed(t, transient)
+	hashes, err := client.HashTransient(transient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	without := func(key string) map[string]string {
+		partial := map[string]string{}
+		for k, v := range hashes {
+			if k != key {
+				partial[k] = v
+			}
+		}
+		return partial
+	}
+	withExtra := map[string][]byte{"extra": []byte("x")}
+	for k, v := range transient {
+		withExtra[k] = v
+	}
+	withoutChange := map[string][]byte{}
+	for k, v := range transient {
+		if k != "changeAddr" {
+			withoutChange[k] = v
+		}
+	}
+
+	//poolA的请求序号1已用于发行
+	valid := txTime.Add(time.Hour).Format(time.RFC3339)
+	cases := []struct {
+		name      string
+		transient map[string][]byte
+		req       string
+		want      string
+	}{
+		{"expired", transient, poolA.signTransfer(t, poolB, 2, txTime.Add(-time.Second).Format(time.RFC3339), hashes), "expired"},
+		{"equal nonce", transient, poolA.signTransfer(t, poolB, 1, valid, hashes), "stale nonce"},
+		{"stale nonce", transient, poolA.signTransfer(t, poolB, 0, valid, hashes), "stale nonce"},
+		{"missing hash entry", transient, poolA.signTransfer(t, poolB, 2, valid, without("newAssetAddr")), "newAssetAddr is not signed"},
+		{"extra transient key", withExtra, poolA.signTransfer(t, poolB, 2, valid, hashes), "extra is not signed"},
+		{"missing transient key", withoutChange, poolA.signTransfer(t, poolB, 2, valid, hashes), "changeAddr is missing"},
+	}
+	for _, c := range cases {
+		res := stub.invoke(poolA.mspID, c.transient, "transfer", c.req)
+		if res.Status == shim.OK {
+			t.Errorf("%s: request accepted", c.name)
+		} else if !strings.Contains(res.Message, c.want) {
+			t.Errorf("%s: message = %q, want %q", c.name, res.Message, c.want)
+		}
+	}
+
+	//被拒绝的请求不消耗请求序号
+	if res := stub.invoke(poolA.mspID, transient, "transfer", poolA.signTransfer(t, poolB, 2, valid, hashes)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := stub.invoke(poolA.mspID, transient, "transfer", poolA.signTransfer(t, poolB, 2, valid, hashes)); res.Status == shim.OK {
+		t.Error("replayed nonce accepted")
+	}
+
+	//金额须为规范形式的字符串，验签在解析请求之前，MockStub不回滚被拒绝交易已记录的序号
+	for i, c := range []struct{ amount, want string }{
+		{`10`, "must be a JSON string"},
+		{`"10.0"`, "is not canonical"},
+	} {
+		nonce := uint64(3 + i)
+		res := stub.invoke(poolA.mspID, transient, "transfer", poolA.resignAmount(t, poolA.signTransfer(t, poolB, nonce, valid, hashes), c.amount))
+		if res.Status == shim.OK || !strings.Contains(res.Message, c.want) {
+			t.Errorf("amount %s: status = %d, message = %q, want %q", c.amount, res.Status, res.Message, c.want)
+		}
+	}
+}
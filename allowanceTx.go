@@ -0,0 +1,88 @@
+package FabricTransaction
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/FabricTransaction/assetPool"
+	"github.com/FabricTransaction/common"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+type ApproveReq struct {
+	FromPool    string        `json:"fromPool"`    //授权资产池ID
+	Spender     string        `json:"spender"`     //被授权资产池ID
+	AssetTypeID string        `json:"assetTypeId"` //资产类型
+	Amount      common.Amount `json:"amount"`      //授权额度，覆盖原有额度
+	SignStruct
+}
+
+type TransferFromReq struct {
+	Spender     string        `json:"spender"`     //被授权资产池ID，由其签名
+	FromPool    string        `json:"fromPool"`    //转出资产池ID
+	ToPool      string        `json:"toPool"`      //转入资产池ID
+	AssetTypeID string        `json:"assetTypeId"` //资产类型
+	Amount      common.Amount `json:"amount"`      //转让量
+	SignStruct
+}
+
+func approveHandler(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	req := ApproveReq{}
+	if err := json.Unmarshal([]byte(args[0]), &req); err != nil {
+		return nil, err
+	}
+
+	var from assetPool.AssetPool
+	if err := common.GetDataByKey(stub, common.OBJECT_TYPE_ASEETPOOL, []string{req.FromPool}, &from); err != nil {
+		return nil, err
+	}
+	ok, err := from.Approve(stub, req.Spender, req.AssetTypeID, req.Amount)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("approve failed")
+	}
+	return nil, nil
+}
+
+//args: owner, spender, assetTypeId
+func allowanceHandler(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) < 3 {
+		return nil, errors.New("need owner, spender and assetTypeId")
+	}
+	allowance, err := assetPool.GetAllowance(stub, args[0], args[1], args[2])
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(allowance)
+}
+
+func transferFromHandler(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	req := TransferFromReq{}
+	if err := json.Unmarshal([]byte(args[0]), &req); err != nil {
+		return nil, err
+	}
+
+	var spender, from, to assetPool.AssetPool
+	if err := common.GetDataByKey(stub, common.OBJECT_TYPE_ASEETPOOL, []string{req.Spender}, &spender); err != nil {
+		return nil, err
+	}
+	if err := common.GetDataByKey(stub, common.OBJECT_TYPE_ASEETPOOL, []string{req.FromPool}, &from); err != nil {
+		return nil, err
+	}
+	if err := common.GetDataByKey(stub, common.OBJECT_TYPE_ASEETPOOL, []string{req.ToPool}, &to); err != nil {
+		return nil, err
+	}
+	if err := to.CheckRecipient(); err != nil {
+		return nil, err
+	}
+	ok, err := spender.TransferFrom(stub, req.AssetTypeID, from, to, req.Amount)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("transferFrom failed")
+	}
+	return nil, nil
+}
@@ -0,0 +1,95 @@
+package FabricTransaction
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/FabricTransaction/assetPool"
+	"github.com/FabricTransaction/common"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+func (s *testStub) approve(t *testing.T, from *testPool, spender *testPool, amount int64) pb.Response {
+	req := &ApproveReq{FromPool: from.addr, Spender: spender.addr, AssetTypeID: "CNY", Amount: common.NewAmountFromInt(amount)}
+	return s.invoke(from.mspID, nil, "approve", from.signReq(t, "approve", req, &req.SignStruct, nil))
+}
+
+func (s *testStub) transferFrom(t *testing.T, spender *testPool, from *testPool, to *testPool, amount int64, transient map[string][]byte) pb.Response {
+	req := &TransferFromReq{Spender: spender.addr, FromPool: from.addr, ToPool: to.addr, AssetTypeID: "CNY", Amount: common.NewAmountFromInt(amount)}
+	return s.invoke(spender.mspID, transient, "transferFrom", spender.signReq(t, "transferFrom", req, &req.SignStruct, transient))
+}
+
+func (s *testStub) checkAllowance(t *testing.T, owner *testPool, spender *testPool, want string) {
+	t.Helper()
+	res := s.invoke("Org1MSP", nil, "allowance", owner.addr, spender.addr, "CNY")
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	var allowance assetPool.Allowance
+	if err := json.Unmarshal(res.Payload, &allowance); err != nil {
+		t.Fatal(err)
+	}
+	if allowance.Value.String() != want {
+		t.Errorf("allowance = %s, want %s", allowance.Value, want)
+	}
+}
+
+//transferFrom扣减授权额度，超出剩余额度的请求被拒绝且不花费资产
+func TestTransferFromAllowance(t *testing.T) {
+	stub := newTestStub(t)
+	poolA := stub.addPool(t, "Org1MSP", "poolA")
+	poolS := stub.addPool(t, "Org2MSP", "poolS")
+	poolB := stub.addPool(t, "Org2MSP", "poolB")
+	stub.issue(t, poolA, "CNY", "100", "a1")
+
+	if res := stub.approve(t, poolA, poolS, 50); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	stub.checkAllowance(t, poolA, poolS, "50")
+	stub.checkAllowance(t, poolA, poolB, "0")
+
+	//未被授权的资产池不能转出
+	if res := stub.transferFrom(t, poolB, poolA, poolB, 10, stub.transferTransient(t, poolA, []string{"a1"}, "b0", "a0")); res.Status == shim.OK {
+		t.Fatal("transferFrom without allowance accepted")
+	}
+
+	if res := stub.transferFrom(t, poolS, poolA, poolB, 30, stub.transferTransient(t, poolA, []string{"a1"}, "b1", "a2")); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	stub.checkAllowance(t, poolA, poolS, "20")
+
+	if res := stub.transferFrom(t, poolS, poolA, poolB, 30, stub.transferTransient(t, poolA, []string{"a2"}, "b2", "a3")); res.Status == shim.OK {
+		t.Fatal("transferFrom beyond allowance accepted")
+	}
+	stub.checkAllowance(t, poolA, poolS, "20")
+	if err := stub.verifyOwnership(t, stub.queryAsset(t, "a2"), poolA); err != nil {
+		t.Errorf("a2 after rejected transferFrom: %v", err)
+	}
+
+	if res := stub.transferFrom(t, poolS, poolA, poolB, 20, stub.transferTransient(t, poolA, []string{"a2"}, "b2", "a3")); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	stub.checkAllowance(t, poolA, poolS, "0")
+	for addr, want := range map[string]struct {
+		pool  *testPool
+		value string
+	}{"b1": {poolB, "30"}, "b2": {poolB, "20"}, "a3": {poolA, "50"}} {
+		a := stub.queryAsset(t, addr)
+		if err := stub.verifyOwnership(t, a, want.pool); err != nil {
+			t.Errorf("%s: %v", addr, err)
+		}
+		if a.Value.String() != want.value {
+			t.Errorf("%s value = %s, want %s", addr, a.Value, want.value)
+		}
+	}
+	if res := stub.transferFrom(t, poolS, poolA, poolB, 1, stub.transferTransient(t, poolA, []string{"a3"}, "b3", "a4")); res.Status == shim.OK {
+		t.Fatal("transferFrom with exhausted allowance accepted")
+	}
+
+	//重新授权覆盖剩余额度
+	if res := stub.approve(t, poolA, poolS, 5); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	stub.checkAllowance(t, poolA, poolS, "5")
+}
@@ -1,37 +1,43 @@
 package asset
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"log"
 
 	"github.com/FabricTransaction/common"
-	"github.com/FabricTransaction/common/securityTool"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 )
 
+const ownershipDomain = "FabricTransaction/asset-ownership/v2"
+
 type Asset struct {
-	AssetAddr       string  `json:"assetAddr"`
-	Value           float64 `json:"value"`
-	AssetTypeID     string  `json:"assetTypeId"`
-	HasTransfered   bool    `json:"hasTransfered"`
-	LogInfo         string  `json:"logInfo,omitempty"`
-	AuthedAssetPool string  `json:"authedAssetPool,omitempty"`
-	Sign            string  `json:"sign"`
+	AssetAddr       string        `json:"assetAddr"`
+	Value           common.Amount `json:"value"`
+	AssetTypeID     string        `json:"assetTypeId"`
+	HasTransfered   bool          `json:"hasTransfered"`
+	LogInfo         string        `json:"logInfo,omitempty"`
+	AuthedAssetPool string        `json:"authedAssetPool,omitempty"`
+	Sign            string        `json:"sign"` //所有权承诺，见OwnershipCommitment
 	// GenerateTime    string  `json:"generateTime"`
 }
 
-func (asset *Asset) AddLogInfo() {
-	// TODO
+//记录最近一次创建或消耗该资产的交易对应的链上流水
+func (asset *Asset) AddLogInfo(stub shim.ChaincodeStubInterface) {
+	asset.LogInfo = common.CHAIN_LOG_PREFIX + stub.GetTxID()
 }
 
-func (asset *Asset) CanTransfer(stub shim.ChaincodeStubInterface, poolID string, assetType string) bool {
-	ok, err := asset.verifySign(stub, poolID)
+//publicKey为花费方资产池的公钥，encryptedAddr为该资产登记的加密资产地址，blinding为解密得到的盲化因子
+func (asset *Asset) CanTransfer(poolID string, publicKey string, assetType string, encryptedAddr string, blinding string) bool {
+	ok, err := asset.verifySign(poolID, publicKey, encryptedAddr, blinding)
 	if err != nil {
 		log.Println("verify asset failed:" + err.Error())
 		return false
 	}
-	return ok && asset.Value >= 0 && asset.HasTransfered == false && assetType == asset.AssetTypeID
+	return ok && asset.Value.Sign() >= 0 && asset.HasTransfered == false && assetType == asset.AssetTypeID
 }
 
 func (asset *Asset) GetAssetInfo(stub shim.ChaincodeStubInterface) (*AssetInfo, error) {
@@ -66,6 +72,9 @@ func GetAssetsByAddrs(stub shim.ChaincodeStubInterface, addrs []string) (*[]Asse
 		if err != nil {
 			return nil, err
 		}
+		if val == nil {
+			return nil, errors.New("asset " + addr + " does not exist")
+		}
 
 		var asset Asset
 		if err = json.Unmarshal(val, &asset); err != nil {
@@ -101,31 +110,6 @@ func (asset *Asset) Store(stub shim.ChaincodeStubInterface) error {
 	return nil
 }
 
-func GetSortedAssetsByAddrs(stub shim.ChaincodeStubInterface, addrs []string) (*[]Asset, float64, error) {
-	var assets []Asset
-	sum := float64(0)
-
-	for _, addr := range addrs {
-		key, err := stub.CreateCompositeKey(common.OBJECT_TYPE_ASSET, []string{addr})
-		if err != nil {
-			return nil, 0, err
-		}
-		val, err := stub.GetState(key)
-		if err != nil {
-			return nil, 0, err
-		}
-
-		var asset Asset
-		if err = json.Unmarshal(val, &asset); err != nil {
-			return nil, 0, err
-		}
-		assets = ascInsert(&assets, asset)
-		sum += asset.Value
-	}
-
-	return &assets, sum, nil
-}
-
 func (asset *Asset) CheckFields() error {
 	if common.IsEmptyStr(asset.AssetAddr) {
 		return errors.New("assetAddr is empty")
@@ -133,7 +117,7 @@ func (asset *Asset) CheckFields() error {
 	if common.IsEmptyStr(asset.AssetTypeID) {
 		return errors.New("AssetTypeID is empty")
 	}
-	if asset.Value < 0 {
+	if asset.Value.Sign() < 0 {
 		return errors.New("invalid value")
 	}
 	if common.IsEmptyStr(asset.Sign) {
@@ -142,7 +126,7 @@ func (asset *Asset) CheckFields() error {
 	return nil
 }
 
-func (asset *Asset) verifySign(stub shim.ChaincodeStubInterface, poolID string) (bool, error) {
+func (asset *Asset) verifySign(poolID string, publicKey string, encryptedAddr string, blinding string) (bool, error) {
 	if common.IsEmptyStr(asset.Sign) {
 		return false, errors.New("Asset's sign is empty")
 	}
@@ -153,21 +137,10 @@ func (asset *Asset) verifySign(stub shim.ChaincodeStubInterface, poolID string)
 		return false, errors.New("poolID is Empty")
 	}
 
-	mspID, err := common.GetMspID(stub)
-	if err != nil {
-		return false, err
-	}
-
-	hashStr := mspID + poolID + asset.AssetAddr
-	signStr, err := securityTool.CalcSHA256Base64Str(hashStr)
-	if err != nil {
-		return false, err
-	}
-
-	return signStr == asset.Sign, nil
+	return OwnershipCommitment(poolID, publicKey, asset.AssetTypeID, asset.AssetAddr, asset.Value, encryptedAddr, blinding) == asset.Sign, nil
 }
 
-func (asset *Asset) AddSign(stub shim.ChaincodeStubInterface, poolID string) error {
+func (asset *Asset) AddSign(poolID string, publicKey string, encryptedAddr string, blinding string) error {
 	if !common.IsEmptyStr(asset.Sign) {
 		return errors.New("Asset's sign exists")
 	}
@@ -178,36 +151,21 @@ func (asset *Asset) AddSign(stub shim.ChaincodeStubInterface, poolID string) err
 		return errors.New("poolID is Empty")
 	}
 
-	mspID, err := common.GetMspID(stub)
-	if err != nil {
-		return err
-	}
-
-	hashStr := mspID + poolID + asset.AssetAddr
-	signStr, err := securityTool.CalcSHA256Base64Str(hashStr)
-	if err != nil {
-		return err
-	}
-
-	asset.Sign = signStr
+	asset.Sign = OwnershipCommitment(poolID, publicKey, asset.AssetTypeID, asset.AssetAddr, asset.Value, encryptedAddr, blinding)
 	return nil
 }
 
-func ascInsert(assets *[]Asset, asset Asset) []Asset {
-	tmpAssets := *assets
-	// var sortedAssets []Asset
-	if assets == nil {
-		return nil
-	}
-	for i, v := range tmpAssets {
-		if v.Value > asset.Value {
-			sortedAssets := make([]Asset, len(tmpAssets)+1)
-			copy(sortedAssets, tmpAssets[:i])
-			copy(sortedAssets[i:], []Asset{asset})
-			copy(sortedAssets[i+1:], tmpAssets[i:])
-			return sortedAssets
-		}
-	}
-	sortedAssets := append(tmpAssets, asset)
-	return sortedAssets
+//资产所有权承诺，绑定所属资产池ID与公钥、资产类型、地址和金额，与提交交易的机构无关
+//encryptedAddr为创建时登记的加密资产地址，花费时据此确认加密资产地址与资产对应
+//blinding为盲化因子，只随加密资产地址交给所属资产池，其他人无法用公开信息逐一试算资产归属
+//该值只由链码写入；花费时按花费方资产池重新计算比对，而花费请求须由该资产池私钥签名（见VerifyReq）
+func OwnershipCommitment(poolID string, publicKey string, assetTypeID string, assetAddr string, value common.Amount, encryptedAddr string, blinding string) string {
+	hash := sha256.New()
+	for _, v := range []string{ownershipDomain, poolID, publicKey, assetTypeID, assetAddr, value.String(), encryptedAddr, blinding} {
+		length := make([]byte, 4)
+		binary.BigEndian.PutUint32(length, uint32(len(v)))
+		hash.Write(length)
+		hash.Write([]byte(v))
+	}
+	return base64.StdEncoding.EncodeToString(hash.Sum(nil))
 }
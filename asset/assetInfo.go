@@ -8,14 +8,18 @@ import (
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 
 	"github.com/FabricTransaction/common"
+	"github.com/FabricTransaction/orgManage"
 )
 
 type AssetInfo struct {
-	AssetTypeID string  `json:"assetTypeId"`
-	AssetName   string  `json:"assetName"`   //资产类型名称
-	AssetSymbol string  `json:"assetSymbol"` //资产简称
-	Decimals    string  `json:"decimals"`    //支持的小数点位数
-	TotalSupply float64 `json:"totalSupply"` //总发行金额
+	AssetTypeID string        `json:"assetTypeId"`
+	AssetName   string        `json:"assetName"`   //资产类型名称
+	AssetSymbol string        `json:"assetSymbol"` //资产简称
+	Decimals    uint8         `json:"decimals"`    //支持的小数点位数
+	TotalSupply common.Amount `json:"totalSupply"` //总发行金额，不限量时须为0
+	Uncapped    bool          `json:"uncapped"`    //为true时不限发行量
+	IssuerMspID string        `json:"issuerMspId"` //发行机构MSP ID，仅该机构可发行
+	Circulating common.Amount `json:"circulating"` //当前流通量，发行时增加，赎回时减少
 }
 
 func (ai *AssetInfo) VerifyFields() error {
@@ -28,13 +32,55 @@ func (ai *AssetInfo) VerifyFields() error {
 	if common.IsEmptyStr(ai.AssetSymbol) {
 		return errors.New("AssetSymbol is empty")
 	}
-	if ai.TotalSupply < 0 {
+	if ai.Decimals > common.MAX_DECIMALS {
+		return fmt.Errorf("decimals cannot exceed %d", common.MAX_DECIMALS)
+	}
+	if ai.Uncapped && ai.TotalSupply.Sign() != 0 {
+		return errors.New("uncapped asset type cannot set total supply")
+	}
+	if !ai.Uncapped && ai.TotalSupply.Sign() <= 0 {
 		return errors.New("invalid supply amount")
 	}
+	if err := ai.TotalSupply.CheckDecimals(ai.Decimals); err != nil {
+		return err
+	}
+	if common.IsEmptyStr(ai.IssuerMspID) {
+		return errors.New("IssuerMspID is empty")
+	}
+	if ai.Circulating.Sign() < 0 || !ai.Uncapped && ai.Circulating.Cmp(ai.TotalSupply) > 0 {
+		return errors.New("invalid circulating amount")
+	}
 
 	return nil
 }
 
+//校验交易量为正数且精度不超过该资产类型支持的小数位数
+func (ai *AssetInfo) CheckAmount(amount common.Amount) error {
+	if amount.Sign() <= 0 {
+		return errors.New("amount must be positive")
+	}
+	if err := amount.CheckDecimals(ai.Decimals); err != nil {
+		return errors.New(ai.AssetTypeID + ": " + err.Error())
+	}
+	return nil
+}
+
+func GetAssetInfoByID(stub shim.ChaincodeStubInterface, assetTypeID string) (*AssetInfo, error) {
+	info := &AssetInfo{}
+	if err := common.GetDataByKey(stub, common.OBJECT_TYPE_ASSET_INFO, []string{assetTypeID}, info); err != nil {
+		return nil, errors.New("get asset type " + assetTypeID + " failed: " + err.Error())
+	}
+	return info, nil
+}
+
+func CheckAmount(stub shim.ChaincodeStubInterface, assetTypeID string, amount common.Amount) error {
+	info, err := GetAssetInfoByID(stub, assetTypeID)
+	if err != nil {
+		return err
+	}
+	return info.CheckAmount(amount)
+}
+
 func (ai *AssetInfo) Store(stub shim.ChaincodeStubInterface) error {
 	err := ai.VerifyFields()
 	if err != nil {
@@ -56,8 +102,18 @@ func (ai *AssetInfo) Init(stub shim.ChaincodeStubInterface, info AssetInfo) erro
 	ai.AssetTypeID = info.AssetTypeID
 	ai.AssetName = info.AssetName
 	ai.AssetSymbol = info.AssetSymbol
-	ai.Decimals = "0"
+	ai.Decimals = info.Decimals
 	ai.TotalSupply = info.TotalSupply
+	ai.Uncapped = info.Uncapped
+	ai.IssuerMspID = info.IssuerMspID
+	ai.Circulating = common.Amount{}
+	if common.IsEmptyStr(ai.IssuerMspID) {
+		mspID, err := common.GetMspID(stub)
+		if err != nil {
+			return err
+		}
+		ai.IssuerMspID = mspID
+	}
 
 	exist, _, _, err := common.CheckExistByKey(stub, common.OBJECT_TYPE_ASSET_INFO, []string{ai.AssetTypeID})
 	if err != nil {
@@ -69,3 +125,38 @@ func (ai *AssetInfo) Init(stub shim.ChaincodeStubInterface, info AssetInfo) erro
 
 	return ai.Store(stub)
 }
+
+//发行资产，仅发行机构可调用，限量资产类型发行后流通量不能超过总发行金额
+func (ai *AssetInfo) Mint(stub shim.ChaincodeStubInterface, amount common.Amount) error {
+	//停用的机构不能继续发行
+	org, err := orgManage.GetInvokerOrg(stub)
+	if err != nil {
+		return err
+	}
+	if org.MspID != ai.IssuerMspID {
+		return errors.New(org.MspID + " is not the issuer of " + ai.AssetTypeID)
+	}
+	if err := ai.CheckAmount(amount); err != nil {
+		return err
+	}
+
+	circulating := ai.Circulating.Add(amount)
+	if !ai.Uncapped && circulating.Cmp(ai.TotalSupply) > 0 {
+		return fmt.Errorf("issue %s exceeds total supply of %s: circulating %s, total %s", amount, ai.AssetTypeID, ai.Circulating, ai.TotalSupply)
+	}
+	ai.Circulating = circulating
+	return ai.Store(stub)
+}
+
+//赎回销毁资产，减少流通量
+func (ai *AssetInfo) Retire(stub shim.ChaincodeStubInterface, amount common.Amount) error {
+	if err := ai.CheckAmount(amount); err != nil {
+		return err
+	}
+	circulating := ai.Circulating.Sub(amount)
+	if circulating.Sign() < 0 {
+		return fmt.Errorf("redeem %s exceeds circulating %s of %s", amount, ai.Circulating, ai.AssetTypeID)
+	}
+	ai.Circulating = circulating
+	return ai.Store(stub)
+}
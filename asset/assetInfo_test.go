@@ -0,0 +1,163 @@
+package asset
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"strconv"
+	"testing"
+
+	"github.com/FabricTransaction/common"
+	"github.com/FabricTransaction/orgManage"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/msp"
+)
+
+//MockStub不提供调用者身份，由issuerStub补充
+type issuerStub struct {
+	*shim.MockStub
+	creator []byte
+	txCount int
+}
+
+func (s *issuerStub) GetCreator() ([]byte, error) {
+	return s.creator, nil
+}
+
+//每次调用为一笔独立交易
+func (s *issuerStub) run(mspID string, f func() error) error {
+	s.creator, _ = proto.Marshal(&msp.SerializedIdentity{Mspid: mspID})
+	s.txCount++
+	txID := "tx" + strconv.Itoa(s.txCount)
+	s.MockTransactionStart(txID)
+	defer s.MockTransactionEnd(txID)
+	return f()
+}
+
+//登记发行机构IssuerMSP与其他机构OtherMSP
+func newIssuerStub(t *testing.T) *issuerStub {
+	stub := &issuerStub{MockStub: shim.NewMockStub("asset", nil)}
+	for _, mspID := range []string{"IssuerMSP", "OtherMSP"} {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pubBytes, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		org := &orgManage.Org{}
+		info := orgManage.Org{MspID: mspID, OrgName: mspID, PublicKey: base64.StdEncoding.EncodeToString(pubBytes)}
+		if err := stub.run(mspID, func() error { return org.Init(stub, info) }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return stub
+}
+
+func newAssetInfo(t *testing.T, stub *issuerStub, assetTypeID string, totalSupply int64, uncapped bool) *AssetInfo {
+	params := AssetInfo{
+		AssetTypeID: assetTypeID,
+		AssetName:   assetTypeID,
+		AssetSymbol: assetTypeID,
+		Decimals:    2,
+		TotalSupply: common.NewAmountFromInt(totalSupply),
+		Uncapped:    uncapped,
+		IssuerMspID: "IssuerMSP",
+	}
+	info := &AssetInfo{}
+	if err := stub.run("IssuerMSP", func() error { return info.Init(stub, params) }); err != nil {
+		t.Fatal(err)
+	}
+	return info
+}
+
+//流通量随资产类型记录保存
+func checkCirculating(t *testing.T, stub *issuerStub, assetTypeID string, want int64) {
+	t.Helper()
+	info, err := GetAssetInfoByID(stub, assetTypeID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Circulating.Cmp(common.NewAmountFromInt(want)) != 0 {
+		t.Errorf("%s circulating %s, want %d", assetTypeID, info.Circulating, want)
+	}
+}
+
+func TestMintSupplyCap(t *testing.T) {
+	stub := newIssuerStub(t)
+	info := newAssetInfo(t, stub, "CNY", 100, false)
+
+	mint := func(mspID string, amount int64) error {
+		return stub.run(mspID, func() error { return info.Mint(stub, common.NewAmountFromInt(amount)) })
+	}
+	retire := func(amount int64) error {
+		return stub.run("IssuerMSP", func() error { return info.Retire(stub, common.NewAmountFromInt(amount)) })
+	}
+	if err := mint("IssuerMSP", 60); err != nil {
+		t.Fatal(err)
+	}
+	if err := mint("IssuerMSP", 50); err == nil {
+		t.Error("issue beyond total supply should be rejected")
+	}
+	if err := mint("OtherMSP", 10); err == nil {
+		t.Error("issue by non-issuer should be rejected")
+	}
+	if err := mint("IssuerMSP", 40); err != nil {
+		t.Fatal("issue up to total supply:", err)
+	}
+	checkCirculating(t, stub, "CNY", 100)
+
+	if err := retire(30); err != nil {
+		t.Fatal(err)
+	}
+	checkCirculating(t, stub, "CNY", 70)
+	if err := mint("IssuerMSP", 30); err != nil {
+		t.Fatal("issue after redeem:", err)
+	}
+	if err := mint("IssuerMSP", 1); err == nil {
+		t.Error("issue beyond total supply after redeem should be rejected")
+	}
+	if err := retire(101); err == nil {
+		t.Error("redeem beyond circulating should be rejected")
+	}
+	checkCirculating(t, stub, "CNY", 100)
+}
+
+func TestMintUncapped(t *testing.T) {
+	stub := newIssuerStub(t)
+	info := newAssetInfo(t, stub, "PTS", 0, true)
+	for _, amount := range []int64{1000, 1000000} {
+		if err := stub.run("IssuerMSP", func() error { return info.Mint(stub, common.NewAmountFromInt(amount)) }); err != nil {
+			t.Fatal("uncapped asset type:", err)
+		}
+	}
+	checkCirculating(t, stub, "PTS", 1001000)
+
+	//停用的发行机构不能继续发行
+	org, err := orgManage.GetOrg(stub, "IssuerMSP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stub.run("OtherMSP", func() error { return org.Disable(stub) }); err != nil {
+		t.Fatal(err)
+	}
+	if err := stub.run("IssuerMSP", func() error { return info.Mint(stub, common.NewAmountFromInt(1)) }); err == nil {
+		t.Error("issue by disabled issuer should be rejected")
+	}
+}
+
+//总发行金额为0不再表示不限量，须显式设置Uncapped
+func TestSupplyFields(t *testing.T) {
+	stub := newIssuerStub(t)
+	for _, params := range []AssetInfo{
+		{TotalSupply: common.NewAmountFromInt(0)},
+		{TotalSupply: common.NewAmountFromInt(-1)},
+		{TotalSupply: common.NewAmountFromInt(100), Uncapped: true},
+	} {
+		params.AssetTypeID, params.AssetName, params.AssetSymbol, params.IssuerMspID = "X", "X", "X", "IssuerMSP"
+		if err := stub.run("IssuerMSP", func() error { return new(AssetInfo).Init(stub, params) }); err == nil {
+			t.Errorf("total supply %s uncapped %v should be rejected", params.TotalSupply, params.Uncapped)
+		}
+	}
+}
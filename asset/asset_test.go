@@ -1,43 +1,44 @@
 package asset
 
 import (
-	"fmt"
 	"testing"
+
+	"github.com/FabricTransaction/common"
 )
 
-func Test_insertSort(t *testing.T) {
-	var s []Asset
-	as1 := Asset{
-		Value: 30,
+func TestCanTransfer(t *testing.T) {
+	asset := Asset{
+		AssetAddr:   "addr1",
+		Value:       common.NewAmountFromInt(10),
+		AssetTypeID: "CNY",
+	}
+	if err := asset.AddSign("poolA", "keyA", "enc1", "blinding"); err != nil {
+		t.Fatal(err)
+	}
+	if !asset.CanTransfer("poolA", "keyA", "CNY", "enc1", "blinding") {
+		t.Error("owner pool should be able to transfer")
+	}
+	if asset.CanTransfer("poolB", "keyA", "CNY", "enc1", "blinding") || asset.CanTransfer("poolA", "keyB", "CNY", "enc1", "blinding") {
+		t.Error("other pool should not be able to transfer")
 	}
-	as2 := Asset{
-		Value: 20,
+	if asset.CanTransfer("poolA", "keyA", "USD", "enc1", "blinding") {
+		t.Error("asset type mismatch should not transfer")
+	}
+	if asset.CanTransfer("poolA", "keyA", "CNY", "enc1", "") || asset.CanTransfer("poolA", "keyA", "CNY", "enc1", "other") {
+		t.Error("wrong blinding should not transfer")
+	}
+	if asset.CanTransfer("poolA", "keyA", "CNY", "enc2", "blinding") {
+		t.Error("another encrypted addr should not transfer")
 	}
 
-	af := ascInsert(&s, as1)
-	fmt.Println(af)
-	af = ascInsert(&af, as2)
-	af = ascInsert(&af, as2)
-	af = ascInsert(&af, as1)
-	// af = insertSort(af, as2)
-	fmt.Println(af)
-	t.Fail()
-	// type args struct {
-	// 	assets *[]Asset
-	// 	asset  Asset
-	// }
-	// tests := []struct {
-	// 	name string
-	// 	args args
-	// 	want *[]Asset
-	// }{
-	// 	// TODO: Add test cases.
-	// }
-	// for _, tt := range tests {
-	// 	t.Run(tt.name, func(t *testing.T) {
-	// 		if got := insertSort(tt.args.assets, tt.args.asset); !reflect.DeepEqual(got, tt.want) {
-	// 			t.Errorf("insertSort() = %v, want %v", got, tt.want)
-	// 		}
-	// 	})
-	// }
+	tampered := asset
+	tampered.Value = common.NewAmountFromInt(100)
+	if tampered.CanTransfer("poolA", "keyA", "CNY", "enc1", "blinding") {
+		t.Error("tampered value should not verify")
+	}
+	spent := asset
+	spent.HasTransfered = true
+	if spent.CanTransfer("poolA", "keyA", "CNY", "enc1", "blinding") {
+		t.Error("spent asset should not transfer")
+	}
 }
@@ -1,10 +1,12 @@
 package asset
 
+import "github.com/FabricTransaction/common"
+
 type PublicAsset struct {
 	Asset
 	OwnerAssetPool string `json:"ownerAssetPool"`
 }
 
-func (pAsset *PublicAsset) Allowance() float64 {
+func (pAsset *PublicAsset) Allowance() common.Amount {
 	return pAsset.Value
 }
@@ -0,0 +1,138 @@
+package assetPool
+
+import (
+	"encoding/json"
+	"errors"
+
+	ast "github.com/FabricTransaction/asset"
+	"github.com/FabricTransaction/common"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+type Allowance struct {
+	Owner       string        `json:"owner"`   //授权资产池ID
+	Spender     string        `json:"spender"` //被授权资产池ID
+	AssetTypeID string        `json:"assetTypeId"`
+	Value       common.Amount `json:"value"` //剩余授权额度
+}
+
+func (allowance *Allowance) Store(stub shim.ChaincodeStubInterface) error {
+	err := allowance.VerifyFields()
+	if err != nil {
+		return err
+	}
+
+	bytes, err := json.Marshal(allowance)
+	if err != nil {
+		return err
+	}
+	key, err := stub.CreateCompositeKey(common.OBJECT_TYPE_ALLOWANCE, []string{allowance.Owner, allowance.Spender, allowance.AssetTypeID})
+	if err != nil {
+		return err
+	}
+	return stub.PutState(key, bytes)
+}
+
+func (allowance *Allowance) VerifyFields() error {
+	if common.IsEmptyStr(allowance.Owner) {
+		return errors.New("allowance's owner is empty")
+	}
+	if common.IsEmptyStr(allowance.Spender) {
+		return errors.New("allowance's spender is empty")
+	}
+	if common.IsEmptyStr(allowance.AssetTypeID) {
+		return errors.New("allowance's assetTypeId is empty")
+	}
+	if allowance.Value.Sign() < 0 {
+		return errors.New("invalid allowance value")
+	}
+	return nil
+}
+
+//未授权时返回额度为0的记录
+func GetAllowance(stub shim.ChaincodeStubInterface, owner string, spender string, assetType string) (*Allowance, error) {
+	allowance := &Allowance{
+		Owner:       owner,
+		Spender:     spender,
+		AssetTypeID: assetType,
+	}
+	exist, _, val, err := common.CheckExistByKey(stub, common.OBJECT_TYPE_ALLOWANCE, []string{owner, spender, assetType})
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		return allowance, nil
+	}
+	if err = json.Unmarshal(val, allowance); err != nil {
+		return nil, err
+	}
+	return allowance, nil
+}
+
+//授权_spender使用本资产池_value量的资产，重复调用时覆盖原有额度
+func (pool *AssetPool) Approve(stub shim.ChaincodeStubInterface, _spender string, assetType string, _value common.Amount) (bool, error) {
+	if _value.Sign() < 0 {
+		return false, errors.New("invalid approve value")
+	}
+	info, err := ast.GetAssetInfoByID(stub, assetType)
+	if err != nil {
+		return false, err
+	}
+	if err := _value.CheckDecimals(info.Decimals); err != nil {
+		return false, err
+	}
+	if _spender == pool.AssetPoolAddr {
+		return false, errors.New("cannot approve to self")
+	}
+	exist, _, _, err := common.CheckExistByKey(stub, common.OBJECT_TYPE_ASEETPOOL, []string{_spender})
+	if err != nil {
+		return false, err
+	}
+	if !exist {
+		return false, errors.New("spender " + _spender + " does not exist")
+	}
+
+	allowance := Allowance{
+		Owner:       pool.AssetPoolAddr,
+		Spender:     _spender,
+		AssetTypeID: assetType,
+		Value:       _value,
+	}
+	if err := allowance.Store(stub); err != nil {
+		return false, err
+	}
+	if err := pool.SetApprovalEvent(stub, _spender, assetType, _value); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (pool *AssetPool) Allowance(stub shim.ChaincodeStubInterface, _spender string, assetType string) (common.Amount, error) {
+	allowance, err := GetAllowance(stub, pool.AssetPoolAddr, _spender, assetType)
+	if err != nil {
+		return common.Amount{}, err
+	}
+	return allowance.Value, nil
+}
+
+//由被授权资产池调用，在授权额度内将_from的资产转入_to，并扣减授权额度
+func (pool *AssetPool) TransferFrom(stub shim.ChaincodeStubInterface, assetType string, _from AssetPool, _to AssetPool, _value common.Amount) (bool, error) {
+	allowance, err := GetAllowance(stub, _from.AssetPoolAddr, pool.AssetPoolAddr, assetType)
+	if err != nil {
+		return false, err
+	}
+	if allowance.Value.Cmp(_value) < 0 {
+		return false, errors.New("transfer amount exceeds allowance")
+	}
+
+	ok, err := _from.Transfer(stub, assetType, _to, _value)
+	if err != nil || !ok {
+		return ok, err
+	}
+
+	allowance.Value = allowance.Value.Sub(_value)
+	if err := allowance.Store(stub); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -1,15 +1,20 @@
 package assetPool
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"io"
+	"time"
 
 	ast "github.com/FabricTransaction/asset"
 	"github.com/FabricTransaction/common"
-	"github.com/FabricTransaction/common/securityTool"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 )
 
+//盲化因子字节数
+const BLINDING_LEN = 32
+
 type AssetAddr struct {
 	AssetPoolAddr    string `json:"assetPoolAddr"`
 	EncryptAssetAddr string `json:"encryptAssetAddr"`
@@ -18,12 +23,45 @@ type AssetAddr struct {
 	HasTransfered    bool   `json:"hasTransfered"`
 }
 
-func GenerateAndStoreAssetAddr(stub shim.ChaincodeStubInterface, asset ast.Asset, pool AssetPool) error {
-	cryptSuite := securityTool.RSATool{}
+//加密资产地址的明文，资产池解密后得到资产地址及其所有权承诺的盲化因子
+type AssetAddrSecret struct {
+	AssetAddr string `json:"assetAddr"`
+	Blinding  string `json:"blinding"`
+}
 
-	ctStr, err := cryptSuite.EncryptByPoolPublicKey([]byte(pool.PublicKey), []byte(asset.AssetAddr))
+//为新资产生成所有权承诺的盲化因子，由transient中的encryptSeed按资产地址派生
+func NewBlinding(stub shim.ChaincodeStubInterface, assetAddr string) (string, error) {
+	random, err := common.GetTxRand(stub, "blinding/"+assetAddr)
 	if err != nil {
-		return err
+		return "", err
+	}
+	blinding := make([]byte, BLINDING_LEN)
+	if _, err := io.ReadFull(random, blinding); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(blinding), nil
+}
+
+//用资产池公钥加密资产地址与盲化因子并登记，资产池凭私钥解密找回属于自己的资产
+//加密随机数由transient中的encryptSeed按资产地址派生，各背书节点写入相同的键值
+//返回加密资产地址，由资产的所有权承诺绑定
+func GenerateAndStoreAssetAddr(stub shim.ChaincodeStubInterface, asset ast.Asset, pool AssetPool, blinding string) (string, error) {
+	cryptSuite, err := pool.GetSecurityTool()
+	if err != nil {
+		return "", err
+	}
+	random, err := common.GetTxRand(stub, "assetAddr/"+asset.AssetAddr)
+	if err != nil {
+		return "", err
+	}
+	secret, err := json.Marshal(AssetAddrSecret{AssetAddr: asset.AssetAddr, Blinding: blinding})
+	if err != nil {
+		return "", err
+	}
+
+	ctStr, err := cryptSuite.EncryptByPoolPublicKey(random, []byte(pool.PublicKey), secret)
+	if err != nil {
+		return "", err
 	}
 	addr := AssetAddr{
 		AssetPoolAddr:    pool.AssetPoolAddr,
@@ -32,16 +70,17 @@ func GenerateAndStoreAssetAddr(stub shim.ChaincodeStubInterface, asset ast.Asset
 		HasTransfered:    false,
 	}
 
-	exist, key, _, err := common.CheckExistByKey(stub, common.OBJECT_TYPE_ASSET_ADDR, []string{addr.AssetPoolAddr, addr.EncryptAssetAddr})
+	exist, _, _, err := common.CheckExistByKey(stub, common.OBJECT_TYPE_ASSET_ADDR, []string{addr.AssetPoolAddr, addr.EncryptAssetAddr})
+	if err != nil {
+		return "", err
+	}
 	if exist {
-		return errors.New("asset addr already exists")
+		return "", errors.New("asset addr already exists")
 	}
-	val, err := json.Marshal(addr)
-	if err != nil {
-		return err
+	if err := addr.StoreAssetAddr(stub); err != nil {
+		return "", err
 	}
-
-	return stub.PutState(key, val)
+	return ctStr, nil
 }
 
 func (assetAddr *AssetAddr) StoreAssetAddr(stub shim.ChaincodeStubInterface) error {
@@ -57,6 +96,69 @@ func (assetAddr *AssetAddr) StoreAssetAddr(stub shim.ChaincodeStubInterface) err
 }
 
 func (assetAddr *AssetAddr) Burn(stub shim.ChaincodeStubInterface) error {
+	txTime, err := common.GetTxTime(stub)
+	if err != nil {
+		return err
+	}
 	assetAddr.HasTransfered = true
+	assetAddr.BurnTime = txTime.Format(time.RFC3339Nano)
 	return assetAddr.StoreAssetAddr(stub)
 }
+
+type AssetAddrPage struct {
+	AssetAddrs   []AssetAddr `json:"assetAddrs"`
+	Bookmark     string      `json:"bookmark"`     //下一页起始位置，为空表示已无更多数据
+	FetchedCount int32       `json:"fetchedCount"` //为填满本页读取的记录总数（过滤前）
+}
+
+//分页查询资产池下的加密资产地址，assetType为空或hasTransfered为nil时不按该条件过滤
+//读取时逐条过滤，不足pageSize条时从书签处继续读取，直到填满一页或已无更多数据
+//每轮只读取本页尚缺的条数，返回的书签总是指向第一条未读取的记录
+func ListAssetAddrs(stub shim.ChaincodeStubInterface, poolAddr string, assetType string, hasTransfered *bool, pageSize int32, bookmark string) (*AssetAddrPage, error) {
+	page := &AssetAddrPage{AssetAddrs: []AssetAddr{}, Bookmark: bookmark}
+	for int32(len(page.AssetAddrs)) < pageSize {
+		fetched, err := page.fill(stub, poolAddr, assetType, hasTransfered, pageSize-int32(len(page.AssetAddrs)))
+		if err != nil {
+			return nil, err
+		}
+		if fetched == 0 || common.IsEmptyStr(page.Bookmark) {
+			break
+		}
+	}
+	return page, nil
+}
+
+//从page.Bookmark处读取至多limit条记录，把符合条件的加入本页并更新书签
+func (page *AssetAddrPage) fill(stub shim.ChaincodeStubInterface, poolAddr string, assetType string, hasTransfered *bool, limit int32) (int32, error) {
+	iter, meta, err := stub.GetStateByPartialCompositeKeyWithPagination(common.OBJECT_TYPE_ASSET_ADDR, []string{poolAddr}, limit, page.Bookmark)
+	if err != nil {
+		return 0, err
+	}
+	defer iter.Close()
+
+	var fetched int32
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return 0, err
+		}
+		fetched++
+		var addr AssetAddr
+		if err := json.Unmarshal(kv.Value, &addr); err != nil {
+			return 0, err
+		}
+		if !common.IsEmptyStr(assetType) && addr.AssetTypeID != assetType {
+			continue
+		}
+		if hasTransfered != nil && addr.HasTransfered != *hasTransfered {
+			continue
+		}
+		page.AssetAddrs = append(page.AssetAddrs, addr)
+	}
+	page.FetchedCount += fetched
+	page.Bookmark = ""
+	if meta != nil {
+		page.Bookmark = meta.Bookmark
+	}
+	return fetched, nil
+}
@@ -0,0 +1,159 @@
+package assetPool
+
+import (
+	"strconv"
+	"testing"
+
+	ast "github.com/FabricTransaction/asset"
+	"github.com/FabricTransaction/common"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/ledger/queryresult"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+//登记属于pool的资产及其加密资产地址
+func storeOwnedAsset(t *testing.T, stub shim.ChaincodeStubInterface, pool AssetPool, addr string, encryptedAddr string) ast.Asset {
+	asset := ast.Asset{AssetAddr: addr, Value: common.NewAmountFromInt(10), AssetTypeID: "CNY"}
+	if err := asset.AddSign(pool.AssetPoolAddr, pool.PublicKey, encryptedAddr, "blinding-"+addr); err != nil {
+		t.Fatal(err)
+	}
+	if err := asset.Store(stub); err != nil {
+		t.Fatal(err)
+	}
+	record := AssetAddr{AssetPoolAddr: pool.AssetPoolAddr, EncryptAssetAddr: encryptedAddr, AssetTypeID: "CNY"}
+	if err := record.StoreAssetAddr(stub); err != nil {
+		t.Fatal(err)
+	}
+	return asset
+}
+
+func getAssetAddr(t *testing.T, stub shim.ChaincodeStubInterface, pool AssetPool, encryptedAddr string) AssetAddr {
+	var record AssetAddr
+	if err := common.GetDataByKey(stub, common.OBJECT_TYPE_ASSET_ADDR, []string{pool.AssetPoolAddr, encryptedAddr}, &record); err != nil {
+		t.Fatal(err)
+	}
+	return record
+}
+
+func TestBurnAssetAddrRequiresMatch(t *testing.T) {
+	stub := shim.NewMockStub("assetPool", nil)
+	stub.MockTransactionStart("tx1")
+	defer stub.MockTransactionEnd("tx1")
+
+	pool := AssetPool{AssetPoolAddr: "poolA", PublicKey: "keyA"}
+	a1 := storeOwnedAsset(t, stub, pool, "a1", "enc-a1")
+	storeOwnedAsset(t, stub, pool, "a2", "enc-a2")
+
+	//a1配上a2的加密资产地址
+	mismatched := SpendInput{AssetAddr: "a1", EncryptedAddr: "enc-a2", Blinding: "blinding-a1"}
+	if err := pool.BurnAssetAddr(stub, "CNY", a1, mismatched); err == nil {
+		t.Fatal("burned another output's asset addr")
+	}
+	if record := getAssetAddr(t, stub, pool, "enc-a2"); record.HasTransfered {
+		t.Errorf("enc-a2 burned: %+v", record)
+	}
+
+	if err := pool.BurnAssetAddr(stub, "CNY", a1, SpendInput{AssetAddr: "a1", EncryptedAddr: "enc-a1", Blinding: "blinding-a1"}); err != nil {
+		t.Fatal(err)
+	}
+	if record := getAssetAddr(t, stub, pool, "enc-a1"); !record.HasTransfered {
+		t.Errorf("enc-a1 not burned: %+v", record)
+	}
+}
+
+//MockStub不支持分页查询，pagedStub按键序模拟：书签为下一页第一条记录的键
+type pagedStub struct {
+	*shim.MockStub
+	queries int
+}
+
+type kvIter struct {
+	kvs []*queryresult.KV
+}
+
+func (it *kvIter) HasNext() bool { return len(it.kvs) > 0 }
+func (it *kvIter) Close() error  { return nil }
+func (it *kvIter) Next() (*queryresult.KV, error) {
+	kv := it.kvs[0]
+	it.kvs = it.kvs[1:]
+	return kv, nil
+}
+
+func (s *pagedStub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	s.queries++
+	iter, err := s.GetStateByPartialCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer iter.Close()
+
+	page := &kvIter{}
+	next := ""
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, nil, err
+		}
+		if kv.Key < bookmark {
+			continue
+		}
+		if int32(len(page.kvs)) == pageSize {
+			next = kv.Key
+			break
+		}
+		page.kvs = append(page.kvs, kv)
+	}
+	return page, &pb.QueryResponseMetadata{FetchedRecordsCount: int32(len(page.kvs)), Bookmark: next}, nil
+}
+
+func TestListAssetAddrsFillsPage(t *testing.T) {
+	stub := &pagedStub{MockStub: shim.NewMockStub("assetPool", nil)}
+	stub.MockTransactionStart("tx1")
+	defer stub.MockTransactionEnd("tx1")
+
+	//enc00..enc09，其中enc01..enc06已花费
+	for i := 0; i < 10; i++ {
+		record := AssetAddr{AssetPoolAddr: "poolA", EncryptAssetAddr: "enc0" + strconv.Itoa(i), AssetTypeID: "CNY", HasTransfered: i >= 1 && i <= 6}
+		if err := record.StoreAssetAddr(stub); err != nil {
+			t.Fatal(err)
+		}
+	}
+	other := AssetAddr{AssetPoolAddr: "poolB", EncryptAssetAddr: "enc10", AssetTypeID: "CNY"}
+	if err := other.StoreAssetAddr(stub); err != nil {
+		t.Fatal(err)
+	}
+
+	unspent := false
+	page, err := ListAssetAddrs(stub, "poolA", "CNY", &unspent, 3, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, addr := range page.AssetAddrs {
+		got = append(got, addr.EncryptAssetAddr)
+	}
+	if len(got) != 3 || got[0] != "enc00" || got[1] != "enc07" || got[2] != "enc08" {
+		t.Fatalf("page %v, want [enc00 enc07 enc08]", got)
+	}
+	if page.FetchedCount != 9 || page.Bookmark == "" {
+		t.Errorf("fetched %d bookmark %q, want 9 records read and a bookmark at enc09", page.FetchedCount, page.Bookmark)
+	}
+
+	page, err = ListAssetAddrs(stub, "poolA", "CNY", &unspent, 3, page.Bookmark)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.AssetAddrs) != 1 || page.AssetAddrs[0].EncryptAssetAddr != "enc09" || page.Bookmark != "" {
+		t.Errorf("last page %+v, want only enc09 and no bookmark", page)
+	}
+
+	//无过滤条件时一次读满
+	stub.queries = 0
+	page, err = ListAssetAddrs(stub, "poolA", "", nil, 4, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.AssetAddrs) != 4 || page.FetchedCount != 4 || stub.queries != 1 {
+		t.Errorf("unfiltered page %d records, fetched %d in %d queries", len(page.AssetAddrs), page.FetchedCount, stub.queries)
+	}
+}
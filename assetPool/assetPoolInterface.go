@@ -7,6 +7,8 @@ import (
 
 	ast "github.com/FabricTransaction/asset"
 	"github.com/FabricTransaction/common"
+	"github.com/FabricTransaction/common/securityTool"
+	"github.com/FabricTransaction/orgManage"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 )
 
@@ -14,27 +16,46 @@ type AssetPool struct {
 	AssetPoolAddr string `json:"assetPoolAddr"`
 	AssetPoolType string `json:"assetPoolType"`
 	PublicKey     string `json:"publicKey"`
+	KeyAlgorithm  string `json:"keyAlgorithm"` //公钥算法，对应securityTool中注册的密钥套件
+	OwnerMspID    string `json:"ownerMspId"`   //创建该资产池的机构MSP ID
 	// Hash          string `json:"hash"`
 }
 
 type AssetPoolInterface interface {
-	Transfer(stub shim.ChaincodeStubInterface, _to AssetPool, _value float64) (bool, error)
+	Transfer(stub shim.ChaincodeStubInterface, assetType string, _to AssetPool, _value common.Amount) (bool, error)
 
-	Approve(stub shim.ChaincodeStubInterface, _spender string, _value float64) (bool, error)
+	TransferFrom(stub shim.ChaincodeStubInterface, assetType string, _from AssetPool, _to AssetPool, _value common.Amount) (bool, error)
 
-	Allowance(stub shim.ChaincodeStubInterface, _owner string)
+	BatchTransfer(stub shim.ChaincodeStubInterface, assetType string, entries []BatchTransferEntry) (*Selection, error)
 
-	SetTransferEvent(stub shim.ChaincodeStubInterface, _from string, _to string, _value float64) error
+	Approve(stub shim.ChaincodeStubInterface, _spender string, assetType string, _value common.Amount) (bool, error)
 
-	SetApprovalEvent(stub shim.ChaincodeStubInterface, _to string, _value float64) error
+	Allowance(stub shim.ChaincodeStubInterface, _spender string, assetType string) (common.Amount, error)
 
-	Issue(stub shim.ChaincodeStubInterface, _value float64, assetTypeInfo ast.AssetInfo) error
+	SetTransferEvent(stub shim.ChaincodeStubInterface, _to string, assetType string, _value common.Amount) error
+
+	SetApprovalEvent(stub shim.ChaincodeStubInterface, _spender string, assetType string, _value common.Amount) error
+
+	Issue(stub shim.ChaincodeStubInterface, assetType string, _value common.Amount) error
+
+	Redeem(stub shim.ChaincodeStubInterface, assetType string, _value common.Amount) error
 }
 
-func (pool *AssetPool) Init(stub shim.ChaincodeStubInterface, addr string, publicKey string, poolType string) error {
+//keyAlgorithm为空时根据公钥推断
+func (pool *AssetPool) Init(stub shim.ChaincodeStubInterface, addr string, publicKey string, keyAlgorithm string, poolType string, ownerMspID string) error {
 	pool.AssetPoolAddr = addr
 	pool.AssetPoolType = poolType
 	pool.PublicKey = publicKey
+	pool.KeyAlgorithm = keyAlgorithm
+	pool.OwnerMspID = ownerMspID
+
+	if common.IsEmptyStr(pool.KeyAlgorithm) && pool.AssetPoolType != common.ASSET_POOL_TYPE_CONTRACT {
+		algorithm, err := securityTool.DetectKeyAlgorithm(publicKey)
+		if err != nil {
+			return err
+		}
+		pool.KeyAlgorithm = algorithm
+	}
 
 	exist, _, _, err := common.CheckExistByKey(stub, common.OBJECT_TYPE_ASEETPOOL, []string{addr})
 	if err != nil {
@@ -65,59 +86,140 @@ func (pool *AssetPool) Store(stub shim.ChaincodeStubInterface) error {
 	return stub.PutState(key, bytes)
 }
 
-func (pool *AssetPool) Transfer(stub shim.ChaincodeStubInterface, assetType string, _to AssetPool, _value float64) (bool, error) {
-	priData, err := stub.GetTransient()
-	if err != nil {
+func (pool *AssetPool) Transfer(stub shim.ChaincodeStubInterface, assetType string, _to AssetPool, _value common.Amount) (bool, error) {
+	if _, err := pool.TransferWithSelection(stub, assetType, _to, _value); err != nil {
 		return false, err
 	}
+	return true, nil
+}
 
-	addrsBytes, ok := priData["assetAddrs"]
+//转账并返回消耗的输入
+func (pool *AssetPool) TransferWithSelection(stub shim.ChaincodeStubInterface, assetType string, _to AssetPool, _value common.Amount) (*Selection, error) {
+	if err := ast.CheckAmount(stub, assetType, _value); err != nil {
+		return nil, err
+	}
+	priData, err := stub.GetTransient()
+	if err != nil {
+		return nil, err
+	}
+	newAssetAddr, ok := priData["newAssetAddr"]
 	if !ok {
-		log.Println("get addrs failed, transient is:")
-		log.Println(priData)
-		return false, errors.New("cannot get addrs data")
+		//transient中有加密随机种子与盲化因子，只记录缺少的键名
+		log.Println("get newAssetAddr failed, transient has no newAssetAddr")
+		return nil, errors.New("cannot get newAssetAddr data")
 	}
 
-	var decryptAddrs []string
-	if err = json.Unmarshal(addrsBytes, &decryptAddrs); err != nil {
-		log.Printf("unmarshal addrs failed, bytes is: %s", string(addrsBytes))
-		return false, err
+	selection, err := pool.SpendAssets(stub, assetType, _value, []string{string(newAssetAddr)})
+	if err != nil {
+		return nil, err
 	}
-	assets, balance, err := ast.GetSortedAssetsByAddrs(stub, decryptAddrs)
+	err = _to.GenerateAndAddAsset(stub, string(newAssetAddr), _value, assetType)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
-	if balance < _value {
-		return false, errors.New("poor balance")
+
+	if err := pool.SetTransferEvent(stub, _to.AssetPoolAddr, assetType, _value); err != nil {
+		return nil, err
 	}
+	return selection, nil
+}
 
-	change, err := pool.BurnAssets(stub, assetType, *assets, _value, common.TX_TYPE_TRANSFER)
+//按transient中的选币策略消耗本资产池的花费输入，找零存入changeAddr
+//newAssetAddrs为本次花费随后生成的新资产地址，与输入、找零地址一起校验
+func (pool *AssetPool) SpendAssets(stub shim.ChaincodeStubInterface, assetType string, _value common.Amount, newAssetAddrs []string) (*Selection, error) {
+	inputs, err := GetSpendInputs(stub)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
-
-	newAssetAddr, ok := priData["newAssetAddr"]
-	if !ok {
-		log.Println("get newAssetAddr failed, transient is:")
-		log.Println(priData)
-		return false, errors.New("cannot get newAssetAddr data")
+	changeAddr, err := GetChangeAddr(stub)
+	if err != nil {
+		return nil, err
 	}
-	err = _to.GenerateAndAddAsset(stub, string(newAssetAddr), _value, assetType)
+	if err := NewTxAddrs().Add(inputs, newAssetAddrs, changeAddr); err != nil {
+		return nil, err
+	}
+	strategy, err := GetSelectStrategy(stub)
 	if err != nil {
-		return false, err
+		return nil, err
+	}
+	selection, err := pool.SelectInputs(stub, assetType, inputs, _value, strategy)
+	if err != nil {
+		return nil, err
 	}
 
+	change, err := pool.BurnAssets(stub, assetType, selection)
+	if err != nil {
+		return nil, err
+	}
 	if change != nil {
-		err := pool.AddAsset(stub, change)
-		if err != nil {
-			return false, err
+		if err := pool.AddAsset(stub, change); err != nil {
+			return nil, err
 		}
 	}
+	return selection, nil
+}
 
-	return true, nil
+//transient中的找零地址，未提供时为空
+func GetChangeAddr(stub shim.ChaincodeStubInterface) (string, error) {
+	priData, err := stub.GetTransient()
+	if err != nil {
+		return "", err
+	}
+	return string(priData["changeAddr"]), nil
+}
+
+//同一交易内读不到本交易的写入：新资产地址、找零地址须互不相同，也不能与输入地址相同，否则后写入的资产会覆盖前者
+//TxAddrs记录一笔交易内已使用的地址，一笔交易中有多次花费时共用同一个
+type TxAddrs struct {
+	inputs  map[string]bool
+	outputs map[string]bool
+}
+
+func NewTxAddrs() *TxAddrs {
+	return &TxAddrs{inputs: map[string]bool{}, outputs: map[string]bool{}}
+}
+
+//登记一次花费的输入、新资产地址与找零地址，changeAddr为空表示没有找零地址
+func (addrs *TxAddrs) Add(inputs []SpendInput, newAssetAddrs []string, changeAddr string) error {
+	for _, v := range inputs {
+		if addrs.inputs[v.AssetAddr] || addrs.inputs[v.EncryptedAddr] {
+			return errors.New("input " + v.AssetAddr + " is spent twice")
+		}
+		if addrs.outputs[v.AssetAddr] {
+			return errors.New("input " + v.AssetAddr + " is an output of the same tx")
+		}
+		addrs.inputs[v.AssetAddr] = true
+		addrs.inputs[v.EncryptedAddr] = true
+	}
+	outputs := append([]string{}, newAssetAddrs...)
+	if !common.IsEmptyStr(changeAddr) {
+		outputs = append(outputs, changeAddr)
+	}
+	for _, v := range outputs {
+		if common.IsEmptyStr(v) {
+			return errors.New("new asset addr is empty")
+		}
+		if addrs.outputs[v] {
+			return errors.New("asset addr " + v + " is used twice")
+		}
+		if addrs.inputs[v] {
+			return errors.New("asset addr " + v + " is the same as an input")
+		}
+		addrs.outputs[v] = true
+	}
+	return nil
 }
 
-func (pool *AssetPool) Issue(stub shim.ChaincodeStubInterface, _value float64, assetTypeInfo ast.AssetInfo) error {
+//发行资产，资产类型需已登记
+func (pool *AssetPool) Issue(stub shim.ChaincodeStubInterface, assetType string, _value common.Amount) error {
+	info, err := ast.GetAssetInfoByID(stub, assetType)
+	if err != nil {
+		return err
+	}
+	if err := info.Mint(stub, _value); err != nil {
+		return err
+	}
+
 	priData, err := stub.GetTransient()
 	if err != nil {
 		return err
@@ -125,11 +227,14 @@ func (pool *AssetPool) Issue(stub shim.ChaincodeStubInterface, _value float64, a
 
 	assetAddr, ok := priData["assetAddr"]
 	if !ok {
-		log.Printf("get assetAddr failed, priData is %s", priData)
-		return errors.New("get assetAddr failed: " + err.Error())
+		log.Println("get assetAddr failed, transient has no assetAddr")
+		return errors.New("get assetAddr failed")
 	}
 
-	return pool.GenerateAndAddAsset(stub, string(assetAddr), _value, assetTypeInfo.AssetTypeID)
+	if err := pool.GenerateAndAddAsset(stub, string(assetAddr), _value, assetType); err != nil {
+		return err
+	}
+	return pool.SetIssueEvent(stub, assetType, _value)
 }
 
 func (pool *AssetPool) AddAsset(stub shim.ChaincodeStubInterface, asset *ast.Asset) error {
@@ -145,18 +250,25 @@ func (pool *AssetPool) AddAsset(stub shim.ChaincodeStubInterface, asset *ast.Ass
 		return errors.New("Invalid addr: asset addr exists")
 	}
 
-	err = asset.AddSign(stub, pool.AssetPoolAddr)
-	if err != nil {
-		return err
+	//合约资产池没有公钥，其托管资产由挂单记录，承诺不做盲化，也没有加密资产地址
+	encryptedAddr, blinding := "", ""
+	if !common.IsEmptyStr(pool.PublicKey) {
+		if blinding, err = NewBlinding(stub, asset.AssetAddr); err != nil {
+			return err
+		}
+		if encryptedAddr, err = GenerateAndStoreAssetAddr(stub, *asset, *pool, blinding); err != nil {
+			return err
+		}
 	}
-
-	if err := asset.Store(stub); err != nil {
+	err = asset.AddSign(pool.AssetPoolAddr, pool.PublicKey, encryptedAddr, blinding)
+	if err != nil {
 		return err
 	}
-	return nil
+	asset.AddLogInfo(stub)
+	return asset.Store(stub)
 }
 
-func (pool *AssetPool) GenerateAndAddAsset(stub shim.ChaincodeStubInterface, addr string, value float64, assetType string) error {
+func (pool *AssetPool) GenerateAndAddAsset(stub shim.ChaincodeStubInterface, addr string, value common.Amount, assetType string) error {
 	asset := ast.Asset{
 		AssetAddr:     addr,
 		Value:         value,
@@ -167,88 +279,66 @@ func (pool *AssetPool) GenerateAndAddAsset(stub shim.ChaincodeStubInterface, add
 	return err
 }
 
-func (pool *AssetPool) BurnAssets(stub shim.ChaincodeStubInterface, assetType string, assets []ast.Asset, _value float64, burnType string) (*ast.Asset, error) {
+//消耗选中的输入及其加密资产地址，有找零时返回找零资产
+func (pool *AssetPool) BurnAssets(stub shim.ChaincodeStubInterface, assetType string, selection *Selection) (*ast.Asset, error) {
+	for _, v := range selection.selected {
+		if err := pool.BurnAssetAddr(stub, assetType, v.asset, v.input); err != nil {
+			return nil, err
+		}
+		v.asset.HasTransfered = true
+		v.asset.AddLogInfo(stub)
+		if err := v.asset.Store(stub); err != nil {
+			return nil, err
+		}
+	}
+
+	if selection.Change.Sign() <= 0 {
+		return nil, nil
+	}
 	priData, err := stub.GetTransient()
 	if err != nil {
 		return nil, err
 	}
-
-	var burnAssetAddrArray []string
-	encryptedAddrsBytes, ok := priData["encryptedAddrs"]
+	changeAssetAddr, ok := priData["changeAddr"]
 	if !ok {
-		return nil, errors.New("no encryptedAddrs")
+		return nil, errors.New("no changeAddr data")
 	}
-	encryptedAddrs := []string{}
-	err = json.Unmarshal(encryptedAddrsBytes, &encryptedAddrs)
-	if err != nil {
-		log.Println("unmarhsal encryptedAddrs failed: %s", encryptedAddrsBytes)
-		return nil, err
+	changeAsset := ast.Asset{
+		AssetAddr:     string(changeAssetAddr),
+		Value:         selection.Change,
+		AssetTypeID:   assetType,
+		HasTransfered: false,
 	}
+	return &changeAsset, nil
+}
 
-	if len(assets) < 1 {
-		log.Println("no asset transfered")
-		return nil, nil
+//消耗花费输入的加密资产地址，加密资产地址须为该资产创建时登记的地址
+func (pool *AssetPool) BurnAssetAddr(stub shim.ChaincodeStubInterface, assetType string, asset ast.Asset, input SpendInput) error {
+	v := input.EncryptedAddr
+	if input.AssetAddr != asset.AssetAddr || !asset.CanTransfer(pool.AssetPoolAddr, pool.PublicKey, assetType, v, input.Blinding) {
+		return errors.New("asset addr " + v + " does not belong to asset " + asset.AssetAddr)
 	}
-
-	for i, v := range assets {
-		if !v.CanTransfer(stub, pool.AssetPoolAddr, assetType) {
-			continue
-		}
-		if _value <= 0 {
-			break
-		}
-		_value -= v.Value
-		v.HasTransfered = true
-		v.AddLogInfo()
-
-		if err := v.Store(stub); err != nil {
-			return nil, err
-		}
-		burnAssetAddrArray = append(burnAssetAddrArray, encryptedAddrs[i])
+	exists, _, val, err := common.CheckExistByKey(stub, common.OBJECT_TYPE_ASSET_ADDR, []string{pool.AssetPoolAddr, v})
+	if err != nil {
+		return errors.New("getState " + v + " failed:" + err.Error())
 	}
-	if _value > 0 {
-		return nil, errors.New("poor balance")
+	if !exists {
+		return errors.New("asset addr " + v + " does not exist")
 	}
 
-	err = pool.BurnAssetAddr(stub, burnAssetAddrArray)
+	addr := AssetAddr{}
+	err = json.Unmarshal(val, &addr)
 	if err != nil {
-		return nil, err
+		return errors.New("unmarshal " + v + " failed:" + err.Error())
 	}
-
-	if _value < 0 {
-		changeAssetAddr, ok := priData["changeAddr"]
-		if !ok {
-			return nil, errors.New("no changeAddr data")
-		}
-		changeAsset := ast.Asset{
-			AssetAddr:     string(changeAssetAddr),
-			Value:         -_value,
-			AssetTypeID:   assetType,
-			HasTransfered: false,
-		}
-		return &changeAsset, nil
+	if addr.HasTransfered {
+		return errors.New("asset addr " + v + " has been burned")
 	}
-	return nil, nil
-}
-
-func (pool *AssetPool) BurnAssetAddr(stub shim.ChaincodeStubInterface, encryptAddrs []string) error {
-	for _, v := range encryptAddrs {
-		exists, _, val, err := common.CheckExistByKey(stub, common.OBJECT_TYPE_ASSET_ADDR, []string{pool.AssetPoolAddr, v})
-		if err != nil {
-			return errors.New("getState " + v + " failed:" + err.Error())
-		}
-		if !exists {
-			continue
-		}
-
-		addr := AssetAddr{}
-		err = json.Unmarshal(val, &addr)
-		if err != nil {
-			return errors.New("unmarshal " + v + " failed:" + err.Error())
-		}
-		if err = addr.Burn(stub); err != nil {
-			return errors.New("burn " + v + " failed:" + err.Error())
-		}
+	if addr.AssetTypeID != assetType {
+		return errors.New("asset addr " + v + " is not of type " + assetType)
+	}
+	if err = addr.Burn(stub); err != nil {
+		return errors.New("burn " + v + " failed:" + err.Error())
 	}
 	return nil
 }
@@ -260,8 +350,84 @@ func (pool *AssetPool) VerifyFields() error {
 	if common.IsEmptyStr(pool.AssetPoolType) {
 		return errors.New("assetPool's type is empty")
 	}
+	if pool.AssetPoolType == common.ASSET_POOL_TYPE_CONTRACT {
+		return nil
+	}
 	if common.IsEmptyStr(pool.PublicKey) {
 		return errors.New("assetPool's publicKey is empty")
 	}
+	if common.IsEmptyStr(pool.OwnerMspID) {
+		return errors.New("assetPool's ownerMspId is empty")
+	}
+	tool, err := securityTool.GetSecurityTool(pool.KeyAlgorithm)
+	if err != nil {
+		return err
+	}
+	if _, err := tool.ParsePublicKey(pool.PublicKey); err != nil {
+		return errors.New("assetPool's publicKey is not a valid " + pool.KeyAlgorithm + " key: " + err.Error())
+	}
+	return nil
+}
+
+//资产池的密钥套件
+func (pool *AssetPool) GetSecurityTool() (securityTool.SecurityTool, error) {
+	return securityTool.GetSecurityTool(pool.KeyAlgorithm)
+}
+
+//校验调用者机构是否为资产池的所属机构，停用的机构不能再操作其资产池
+func (pool *AssetPool) CheckOwner(stub shim.ChaincodeStubInterface) error {
+	org, err := orgManage.GetInvokerOrg(stub)
+	if err != nil {
+		return err
+	}
+	if org.MspID != pool.OwnerMspID {
+		return errors.New("asset pool " + pool.AssetPoolAddr + " is not owned by " + org.MspID)
+	}
+	return nil
+}
+
+//合约资产池的资产只能随挂单、锁定由链码释放，用户发起的转账不能以其为接收方
+func (pool *AssetPool) CheckRecipient() error {
+	if pool.AssetPoolType == common.ASSET_POOL_TYPE_CONTRACT {
+		return errors.New("cannot transfer to contract asset pool " + pool.AssetPoolAddr)
+	}
 	return nil
 }
+
+func (pool *AssetPool) SetTransferEvent(stub shim.ChaincodeStubInterface, _to string, assetType string, _value common.Amount) error {
+	return common.SetTxEvent(stub, common.TxEvent{
+		TxType:      common.TX_TYPE_TRANSFER,
+		AssetTypeID: assetType,
+		FromPool:    pool.AssetPoolAddr,
+		ToPool:      _to,
+		Amount:      _value,
+	})
+}
+
+func (pool *AssetPool) SetIssueEvent(stub shim.ChaincodeStubInterface, assetType string, _value common.Amount) error {
+	return common.SetTxEvent(stub, common.TxEvent{
+		TxType:      common.TX_TYPE_ISSUE,
+		AssetTypeID: assetType,
+		ToPool:      pool.AssetPoolAddr,
+		Amount:      _value,
+	})
+}
+
+func (pool *AssetPool) SetApprovalEvent(stub shim.ChaincodeStubInterface, _spender string, assetType string, _value common.Amount) error {
+	return common.SetTxEvent(stub, common.TxEvent{
+		TxType:      common.TX_TYPE_APPROVE,
+		AssetTypeID: assetType,
+		FromPool:    pool.AssetPoolAddr,
+		ToPool:      _spender,
+		Amount:      _value,
+	})
+}
+
+func (pool *AssetPool) SetRedeemEvent(stub shim.ChaincodeStubInterface, assetType string, _value common.Amount) error {
+	return common.SetTxEvent(stub, common.TxEvent{
+		TxType:      common.TX_TYPE_REDEEM,
+		AssetTypeID: assetType,
+		FromPool:    pool.AssetPoolAddr,
+		Amount:      _value,
+	})
+}
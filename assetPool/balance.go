@@ -0,0 +1,40 @@
+package assetPool
+
+import (
+	ast "github.com/FabricTransaction/asset"
+	"github.com/FabricTransaction/common"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+type Balance struct {
+	AssetPoolAddr string        `json:"assetPoolAddr"`
+	AssetTypeID   string        `json:"assetTypeId"`
+	Balance       common.Amount `json:"balance"`
+}
+
+//统计transient中花费输入（格式同GetSpendInputs）对应的、属于本资产池且未消耗的资产总额，只读不写
+func (pool *AssetPool) BalanceOf(stub shim.ChaincodeStubInterface, assetType string) (*Balance, error) {
+	inputs, err := GetSpendInputs(stub)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]string, len(inputs))
+	for i, v := range inputs {
+		addrs[i] = v.AssetAddr
+	}
+	assets, err := ast.GetAssetsByAddrs(stub, addrs)
+	if err != nil {
+		return nil, err
+	}
+
+	balance := &Balance{
+		AssetPoolAddr: pool.AssetPoolAddr,
+		AssetTypeID:   assetType,
+	}
+	for i, v := range *assets {
+		if v.CanTransfer(pool.AssetPoolAddr, pool.PublicKey, assetType, inputs[i].EncryptedAddr, inputs[i].Blinding) {
+			balance.Balance = balance.Balance.Add(v.Value)
+		}
+	}
+	return balance, nil
+}
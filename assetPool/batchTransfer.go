@@ -0,0 +1,55 @@
+package assetPool
+
+import (
+	"errors"
+
+	ast "github.com/FabricTransaction/asset"
+	"github.com/FabricTransaction/common"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+//批量转账中的一笔
+type BatchTransferEntry struct {
+	To           AssetPool
+	Amount       common.Amount
+	NewAssetAddr string //接收方新资产地址
+}
+
+//一次选币向多个接收方转账：每个接收方生成一个新资产，找零只生成一个
+func (pool *AssetPool) BatchTransfer(stub shim.ChaincodeStubInterface, assetType string, entries []BatchTransferEntry) (*Selection, error) {
+	if len(entries) == 0 {
+		return nil, errors.New("no batch transfer entries")
+	}
+	total := common.Amount{}
+	newAssetAddrs := make([]string, 0, len(entries))
+	for _, v := range entries {
+		if err := ast.CheckAmount(stub, assetType, v.Amount); err != nil {
+			return nil, err
+		}
+		if v.To.AssetPoolAddr == pool.AssetPoolAddr {
+			return nil, errors.New("cannot batch transfer to the sending asset pool")
+		}
+		if err := v.To.CheckRecipient(); err != nil {
+			return nil, err
+		}
+		if common.IsEmptyStr(v.NewAssetAddr) {
+			return nil, errors.New("newAssetAddr for " + v.To.AssetPoolAddr + " is empty")
+		}
+		newAssetAddrs = append(newAssetAddrs, v.NewAssetAddr)
+		total = total.Add(v.Amount)
+	}
+
+	selection, err := pool.SpendAssets(stub, assetType, total, newAssetAddrs)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range entries {
+		if err := v.To.GenerateAndAddAsset(stub, v.NewAssetAddr, v.Amount, assetType); err != nil {
+			return nil, err
+		}
+		if err := pool.SetTransferEvent(stub, v.To.AssetPoolAddr, assetType, v.Amount); err != nil {
+			return nil, err
+		}
+	}
+	return selection, nil
+}
@@ -0,0 +1,234 @@
+package assetPool
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+
+	ast "github.com/FabricTransaction/asset"
+	"github.com/FabricTransaction/common"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+//精确匹配、最小找零需要枚举输入组合，候选输入数超过该值时拒绝
+const MAX_SEARCH_INPUTS = 16
+
+//花费输入：明文资产地址与其加密资产地址成对提供，并附上解密得到的盲化因子
+type SpendInput struct {
+	AssetAddr     string `json:"assetAddr"`
+	EncryptedAddr string `json:"encryptedAddr"`
+	Blinding      string `json:"blinding"`
+}
+
+//返回结果中不包含盲化因子
+type SelectedInput struct {
+	AssetAddr     string        `json:"assetAddr"`
+	EncryptedAddr string        `json:"encryptedAddr"`
+	Value         common.Amount `json:"value"`
+}
+
+//选币结果，记录实际消耗的输入
+type Selection struct {
+	Strategy string          `json:"strategy"`
+	Inputs   []SelectedInput `json:"inputs"`
+	Total    common.Amount   `json:"total"`  //消耗的输入总额
+	Change   common.Amount   `json:"change"` //找零
+	selected []candidate     //选中的输入及资产，与Inputs一一对应
+}
+
+type candidate struct {
+	input SpendInput
+	asset ast.Asset
+}
+
+//读取transient中的花费输入
+//优先使用inputs（[{assetAddr, encryptedAddr, blinding}]），否则按下标配对assetAddrs、encryptedAddrs与blindings
+func GetSpendInputs(stub shim.ChaincodeStubInterface) ([]SpendInput, error) {
+	priData, err := stub.GetTransient()
+	if err != nil {
+		return nil, err
+	}
+
+	var inputs []SpendInput
+	if inputsBytes, ok := priData["inputs"]; ok {
+		if err := json.Unmarshal(inputsBytes, &inputs); err != nil {
+			return nil, errors.New("unmarshal inputs failed: " + err.Error())
+		}
+	} else {
+		addrsBytes, ok := priData["assetAddrs"]
+		if !ok {
+			return nil, errors.New("cannot get addrs data")
+		}
+		encryptedAddrsBytes, ok := priData["encryptedAddrs"]
+		if !ok {
+			return nil, errors.New("no encryptedAddrs")
+		}
+		blindingsBytes, ok := priData["blindings"]
+		if !ok {
+			return nil, errors.New("no blindings")
+		}
+		var addrs, encryptedAddrs, blindings []string
+		if err := json.Unmarshal(addrsBytes, &addrs); err != nil {
+			return nil, errors.New("unmarshal assetAddrs failed: " + err.Error())
+		}
+		if err := json.Unmarshal(encryptedAddrsBytes, &encryptedAddrs); err != nil {
+			return nil, errors.New("unmarshal encryptedAddrs failed: " + err.Error())
+		}
+		if err := json.Unmarshal(blindingsBytes, &blindings); err != nil {
+			return nil, errors.New("unmarshal blindings failed: " + err.Error())
+		}
+		if len(addrs) != len(encryptedAddrs) || len(addrs) != len(blindings) {
+			return nil, errors.New("assetAddrs, encryptedAddrs and blindings have different lengths")
+		}
+		for i := range addrs {
+			inputs = append(inputs, SpendInput{AssetAddr: addrs[i], EncryptedAddr: encryptedAddrs[i], Blinding: blindings[i]})
+		}
+	}
+
+	seenAddrs := map[string]bool{}
+	seenEncrypted := map[string]bool{}
+	for _, v := range inputs {
+		if common.IsEmptyStr(v.AssetAddr) || common.IsEmptyStr(v.EncryptedAddr) {
+			return nil, errors.New("input addr is empty")
+		}
+		if seenAddrs[v.AssetAddr] || seenEncrypted[v.EncryptedAddr] {
+			return nil, errors.New("duplicate input " + v.AssetAddr)
+		}
+		seenAddrs[v.AssetAddr] = true
+		seenEncrypted[v.EncryptedAddr] = true
+	}
+	return inputs, nil
+}
+
+//选币策略，默认从小到大
+func GetSelectStrategy(stub shim.ChaincodeStubInterface) (string, error) {
+	priData, err := stub.GetTransient()
+	if err != nil {
+		return "", err
+	}
+	strategy, ok := priData["selectStrategy"]
+	if !ok || common.IsEmptyStr(string(strategy)) {
+		return common.SELECT_STRATEGY_SMALLEST_FIRST, nil
+	}
+	return string(strategy), nil
+}
+
+//从输入中筛选本资产池可花费的资产，按策略选出不少于_value的输入
+func (pool *AssetPool) SelectInputs(stub shim.ChaincodeStubInterface, assetType string, inputs []SpendInput, _value common.Amount, strategy string) (*Selection, error) {
+	addrs := make([]string, len(inputs))
+	for i, v := range inputs {
+		addrs[i] = v.AssetAddr
+	}
+	assets, err := ast.GetAssetsByAddrs(stub, addrs)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []candidate
+	for i, v := range *assets {
+		if v.CanTransfer(pool.AssetPoolAddr, pool.PublicKey, assetType, inputs[i].EncryptedAddr, inputs[i].Blinding) {
+			candidates = append(candidates, candidate{input: inputs[i], asset: v})
+		}
+	}
+	return selectCandidates(candidates, _value, strategy)
+}
+
+func selectCandidates(candidates []candidate, _value common.Amount, strategy string) (*Selection, error) {
+	if _value.Sign() <= 0 {
+		return nil, errors.New("invalid value " + _value.String())
+	}
+	balance := common.Amount{}
+	for _, v := range candidates {
+		balance = balance.Add(v.asset.Value)
+	}
+	if balance.Cmp(_value) < 0 {
+		return nil, errors.New("poor balance")
+	}
+
+	//按金额、地址排序，保证各背书节点选出相同的输入
+	sorted := make([]candidate, len(candidates))
+	copy(sorted, candidates)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if c := sorted[i].asset.Value.Cmp(sorted[j].asset.Value); c != 0 {
+			return c < 0
+		}
+		return sorted[i].input.AssetAddr < sorted[j].input.AssetAddr
+	})
+
+	var selected []candidate
+	switch strategy {
+	case common.SELECT_STRATEGY_SMALLEST_FIRST:
+		selected = takeUntil(sorted, _value)
+	case common.SELECT_STRATEGY_LARGEST_FIRST:
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].asset.Value.Cmp(sorted[j].asset.Value) > 0
+		})
+		selected = takeUntil(sorted, _value)
+	case common.SELECT_STRATEGY_EXACT_MATCH, common.SELECT_STRATEGY_MIN_CHANGE:
+		if len(sorted) > MAX_SEARCH_INPUTS {
+			return nil, errors.New("too many inputs for strategy " + strategy)
+		}
+		selected = searchSubset(sorted, _value)
+		if strategy == common.SELECT_STRATEGY_EXACT_MATCH && sumCandidates(selected).Cmp(_value) != 0 {
+			return nil, errors.New("no inputs match " + _value.String() + " exactly")
+		}
+	default:
+		return nil, errors.New("unknown select strategy: " + strategy)
+	}
+
+	selection := &Selection{Strategy: strategy, Inputs: []SelectedInput{}}
+	selection.selected = selected
+	for _, v := range selected {
+		selection.Inputs = append(selection.Inputs, SelectedInput{AssetAddr: v.input.AssetAddr, EncryptedAddr: v.input.EncryptedAddr, Value: v.asset.Value})
+	}
+	selection.Total = sumCandidates(selected)
+	selection.Change = selection.Total.Sub(_value)
+	return selection, nil
+}
+
+func takeUntil(sorted []candidate, _value common.Amount) []candidate {
+	sum := common.Amount{}
+	for i, v := range sorted {
+		sum = sum.Add(v.asset.Value)
+		if sum.Cmp(_value) >= 0 {
+			return sorted[:i+1]
+		}
+	}
+	return sorted
+}
+
+func sumCandidates(candidates []candidate) common.Amount {
+	sum := common.Amount{}
+	for _, v := range candidates {
+		sum = sum.Add(v.asset.Value)
+	}
+	return sum
+}
+
+//枚举输入组合，找出总额不少于_value且找零最小的组合，找零相同时取输入最少的
+func searchSubset(sorted []candidate, _value common.Amount) []candidate {
+	var best []candidate
+	var bestSum common.Amount
+	current := []candidate{}
+
+	var search func(i int, sum common.Amount)
+	search = func(i int, sum common.Amount) {
+		if sum.Cmp(_value) >= 0 {
+			if best == nil || sum.Cmp(bestSum) < 0 || sum.Cmp(bestSum) == 0 && len(current) < len(best) {
+				best = append([]candidate{}, current...)
+				bestSum = sum
+			}
+			return
+		}
+		if best != nil && bestSum.Cmp(_value) == 0 && len(current) >= len(best) {
+			return
+		}
+		for j := i; j < len(sorted); j++ {
+			current = append(current, sorted[j])
+			search(j+1, sum.Add(sorted[j].asset.Value))
+			current = current[:len(current)-1]
+		}
+	}
+	search(0, common.Amount{})
+	return best
+}
@@ -0,0 +1,95 @@
+package assetPool
+
+import (
+	"testing"
+
+	ast "github.com/FabricTransaction/asset"
+	"github.com/FabricTransaction/common"
+)
+
+func newCandidates(values ...string) []candidate {
+	var candidates []candidate
+	for i, v := range values {
+		value, _ := common.NewAmount(v)
+		addr := string(rune('a' + i))
+		candidates = append(candidates, candidate{
+			input: SpendInput{AssetAddr: addr, EncryptedAddr: "enc-" + addr},
+			asset: ast.Asset{AssetAddr: addr, Value: value},
+		})
+	}
+	return candidates
+}
+
+func TestSelectCandidates(t *testing.T) {
+	//a=30 b=20 c=20 d=5 e=12.5
+	candidates := newCandidates("30", "20", "20", "5", "12.5")
+	tests := []struct {
+		strategy string
+		value    string
+		inputs   []string
+		change   string
+	}{
+		{common.SELECT_STRATEGY_SMALLEST_FIRST, "17", []string{"d", "e"}, "0.5"},
+		{common.SELECT_STRATEGY_SMALLEST_FIRST, "40", []string{"d", "e", "b", "c"}, "17.5"},
+		{common.SELECT_STRATEGY_LARGEST_FIRST, "40", []string{"a", "b"}, "10"},
+		{common.SELECT_STRATEGY_EXACT_MATCH, "37.5", []string{"d", "e", "b"}, "0"},
+		{common.SELECT_STRATEGY_EXACT_MATCH, "50", []string{"b", "a"}, "0"},
+		{common.SELECT_STRATEGY_MIN_CHANGE, "36", []string{"e", "b", "d"}, "1.5"},
+		{common.SELECT_STRATEGY_MIN_CHANGE, "20", []string{"b"}, "0"},
+	}
+	for _, tt := range tests {
+		value, _ := common.NewAmount(tt.value)
+		selection, err := selectCandidates(candidates, value, tt.strategy)
+		if err != nil {
+			t.Errorf("%s %s: %v", tt.strategy, tt.value, err)
+			continue
+		}
+		var got []string
+		for _, v := range selection.Inputs {
+			got = append(got, v.AssetAddr)
+			if v.EncryptedAddr != "enc-"+v.AssetAddr {
+				t.Errorf("%s: input %s paired with %s", tt.strategy, v.AssetAddr, v.EncryptedAddr)
+			}
+		}
+		if !sameAddrs(got, tt.inputs) {
+			t.Errorf("%s %s: inputs = %v, want %v", tt.strategy, tt.value, got, tt.inputs)
+		}
+		if selection.Change.String() != tt.change {
+			t.Errorf("%s %s: change = %s, want %s", tt.strategy, tt.value, selection.Change, tt.change)
+		}
+		if selection.Total.Sub(selection.Change).Cmp(value) != 0 {
+			t.Errorf("%s %s: total %s - change %s != value", tt.strategy, tt.value, selection.Total, selection.Change)
+		}
+	}
+
+	for _, tt := range []struct {
+		strategy string
+		value    string
+	}{
+		{common.SELECT_STRATEGY_SMALLEST_FIRST, "88"},
+		{common.SELECT_STRATEGY_EXACT_MATCH, "1"},
+		{"RANDOM", "1"},
+		{common.SELECT_STRATEGY_SMALLEST_FIRST, "0"},
+	} {
+		value, _ := common.NewAmount(tt.value)
+		if _, err := selectCandidates(candidates, value, tt.strategy); err == nil {
+			t.Errorf("%s %s should fail", tt.strategy, tt.value)
+		}
+	}
+}
+
+func sameAddrs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := map[string]bool{}
+	for _, v := range a {
+		seen[v] = true
+	}
+	for _, v := range b {
+		if !seen[v] {
+			return false
+		}
+	}
+	return true
+}
@@ -1 +1,191 @@
 package assetPool
+
+import (
+	"encoding/json"
+	"errors"
+
+	ast "github.com/FabricTransaction/asset"
+	"github.com/FabricTransaction/common"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+//合约资产池：没有机构密钥，其资产只能由链码逻辑托管、释放
+type ContractAssetPool struct {
+	AssetPool
+}
+
+//卖方挂出的待售资产，托管于合约资产池
+type Listing struct {
+	ListingID   string        `json:"listingId"`   //挂单ID，即挂单交易ID
+	Seller      string        `json:"seller"`      //卖方资产池ID
+	AssetTypeID string        `json:"assetTypeId"` //待售资产类型
+	Amount      common.Amount `json:"amount"`      //待售资产量
+	PriceTypeID string        `json:"priceTypeId"` //计价资产类型
+	Price       common.Amount `json:"price"`       //总价
+	AssetAddr   string        `json:"assetAddr"`   //托管资产地址
+	Buyer       string        `json:"buyer,omitempty"`
+	Status      string        `json:"status"`
+}
+
+func (cp *ContractAssetPool) Init(stub shim.ChaincodeStubInterface) error {
+	cp.AssetPoolAddr = common.CONTRACT_ASSET_POOL_ADDR
+	cp.AssetPoolType = common.ASSET_POOL_TYPE_CONTRACT
+
+	exist, _, _, err := common.CheckExistByKey(stub, common.OBJECT_TYPE_ASEETPOOL, []string{cp.AssetPoolAddr})
+	if err != nil {
+		return err
+	}
+	if exist {
+		return nil
+	}
+	return cp.Store(stub)
+}
+
+func GetContractAssetPool(stub shim.ChaincodeStubInterface) (*ContractAssetPool, error) {
+	cp := &ContractAssetPool{}
+	if err := common.GetDataByKey(stub, common.OBJECT_TYPE_ASEETPOOL, []string{common.CONTRACT_ASSET_POOL_ADDR}, &cp.AssetPool); err != nil {
+		return nil, errors.New("get contract asset pool failed: " + err.Error())
+	}
+	if cp.AssetPoolType != common.ASSET_POOL_TYPE_CONTRACT {
+		return nil, errors.New("asset pool " + cp.AssetPoolAddr + " is not a contract asset pool")
+	}
+	return cp, nil
+}
+
+//将卖方已授权给合约资产池的资产转入托管，托管资产地址取自transient中的newAssetAddr
+func (cp *ContractAssetPool) Escrow(stub shim.ChaincodeStubInterface, seller AssetPool, assetType string, amount common.Amount, priceType string, price common.Amount) (*Listing, error) {
+	if err := ast.CheckAmount(stub, assetType, amount); err != nil {
+		return nil, err
+	}
+	if err := ast.CheckAmount(stub, priceType, price); err != nil {
+		return nil, errors.New("invalid listing price: " + err.Error())
+	}
+
+	priData, err := stub.GetTransient()
+	if err != nil {
+		return nil, err
+	}
+	escrowAddr, ok := priData["newAssetAddr"]
+	if !ok {
+		return nil, errors.New("cannot get newAssetAddr data")
+	}
+
+	ok, err = cp.TransferFrom(stub, assetType, seller, cp.AssetPool, amount)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("escrow failed")
+	}
+
+	listing := &Listing{
+		ListingID:   stub.GetTxID(),
+		Seller:      seller.AssetPoolAddr,
+		AssetTypeID: assetType,
+		Amount:      amount,
+		PriceTypeID: priceType,
+		Price:       price,
+		AssetAddr:   string(escrowAddr),
+		Status:      common.LISTING_STATUS_OPEN,
+	}
+	if err := listing.Store(stub); err != nil {
+		return nil, err
+	}
+	return listing, nil
+}
+
+//将托管资产释放给买方
+func (cp *ContractAssetPool) Release(stub shim.ChaincodeStubInterface, listing *Listing, buyer AssetPool, newAssetAddr string) error {
+	if err := cp.releaseListing(stub, listing, buyer, newAssetAddr); err != nil {
+		return err
+	}
+	listing.Buyer = buyer.AssetPoolAddr
+	listing.Status = common.LISTING_STATUS_SOLD
+	return listing.Store(stub)
+}
+
+//撤单，将托管资产退回卖方
+func (cp *ContractAssetPool) Refund(stub shim.ChaincodeStubInterface, listing *Listing, newAssetAddr string) error {
+	var seller AssetPool
+	if err := common.GetDataByKey(stub, common.OBJECT_TYPE_ASEETPOOL, []string{listing.Seller}, &seller); err != nil {
+		return err
+	}
+	if err := cp.releaseListing(stub, listing, seller, newAssetAddr); err != nil {
+		return err
+	}
+	listing.Status = common.LISTING_STATUS_CANCELLED
+	return listing.Store(stub)
+}
+
+func (cp *ContractAssetPool) releaseListing(stub shim.ChaincodeStubInterface, listing *Listing, _to AssetPool, newAssetAddr string) error {
+	if listing.Status != common.LISTING_STATUS_OPEN {
+		return errors.New("listing " + listing.ListingID + " is " + listing.Status)
+	}
+	if err := cp.releaseTo(stub, listing.AssetAddr, listing.AssetTypeID, listing.Amount, _to, newAssetAddr); err != nil {
+		return errors.New("release listing " + listing.ListingID + " failed: " + err.Error())
+	}
+	return nil
+}
+
+//消耗托管资产，为_to生成等额的新资产
+func (cp *ContractAssetPool) releaseTo(stub shim.ChaincodeStubInterface, assetAddr string, assetType string, amount common.Amount, _to AssetPool, newAssetAddr string) error {
+	assets, err := ast.GetAssetsByAddrs(stub, []string{assetAddr})
+	if err != nil {
+		return err
+	}
+	escrowed := (*assets)[0]
+	if escrowed.HasTransfered || escrowed.AssetTypeID != assetType || escrowed.Value.Cmp(amount) != 0 {
+		return errors.New("escrowed asset is invalid")
+	}
+	escrowed.HasTransfered = true
+	escrowed.AddLogInfo(stub)
+	if err := escrowed.Store(stub); err != nil {
+		return err
+	}
+
+	if err := _to.GenerateAndAddAsset(stub, newAssetAddr, amount, assetType); err != nil {
+		return err
+	}
+	return cp.SetTransferEvent(stub, _to.AssetPoolAddr, assetType, amount)
+}
+
+func (listing *Listing) Store(stub shim.ChaincodeStubInterface) error {
+	err := listing.VerifyFields()
+	if err != nil {
+		return err
+	}
+
+	bytes, err := json.Marshal(listing)
+	if err != nil {
+		return err
+	}
+	key, err := stub.CreateCompositeKey(common.OBJECT_TYPE_LISTING, []string{listing.ListingID})
+	if err != nil {
+		return err
+	}
+	return stub.PutState(key, bytes)
+}
+
+func (listing *Listing) VerifyFields() error {
+	if common.IsEmptyStr(listing.ListingID) {
+		return errors.New("listingId is empty")
+	}
+	if common.IsEmptyStr(listing.Seller) {
+		return errors.New("listing's seller is empty")
+	}
+	if common.IsEmptyStr(listing.AssetTypeID) {
+		return errors.New("listing's assetTypeId is empty")
+	}
+	if common.IsEmptyStr(listing.AssetAddr) {
+		return errors.New("listing's assetAddr is empty")
+	}
+	return nil
+}
+
+func GetListing(stub shim.ChaincodeStubInterface, listingID string) (*Listing, error) {
+	listing := &Listing{}
+	if err := common.GetDataByKey(stub, common.OBJECT_TYPE_LISTING, []string{listingID}, listing); err != nil {
+		return nil, errors.New("get listing " + listingID + " failed: " + err.Error())
+	}
+	return listing, nil
+}
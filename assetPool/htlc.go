@@ -0,0 +1,221 @@
+package assetPool
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/FabricTransaction/common"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/core/chaincode/shim/ext/statebased"
+)
+
+//哈希时间锁：锁定的资产托管于合约资产池，超时前接收方出示原像即可领取，超时后退回发送方
+//哈希锁为原像的SHA-256，均以十六进制表示，与以太坊上常见的HTLC合约一致
+type HashTimeLock struct {
+	LockID      string        `json:"lockId"`      //锁定ID，即锁定交易ID
+	Sender      string        `json:"sender"`      //发送方资产池ID
+	Recipient   string        `json:"recipient"`   //接收方资产池ID
+	AssetTypeID string        `json:"assetTypeId"` //锁定资产类型
+	Amount      common.Amount `json:"amount"`      //锁定量
+	HashLock    string        `json:"hashLock"`    //原像的SHA-256，十六进制
+	Timeout     string        `json:"timeout"`     //超时时间，RFC3339格式，以交易时间戳判断，时间戳须在背书节点时钟允许偏差内
+	AssetAddr   string        `json:"assetAddr"`   //托管资产地址
+	Preimage    string        `json:"preimage,omitempty"`
+	Status      string        `json:"status"`
+}
+
+//发送方将资产转入合约资产池托管，托管资产地址取自transient中的newAssetAddr
+func (cp *ContractAssetPool) Lock(stub shim.ChaincodeStubInterface, sender AssetPool, recipient AssetPool, assetType string, amount common.Amount, hashLock string, timeout string) (*HashTimeLock, error) {
+	hashLock = strings.ToLower(hashLock)
+	if hashBytes, err := hex.DecodeString(hashLock); err != nil || len(hashBytes) != sha256.Size {
+		return nil, errors.New("hashLock must be a hex encoded SHA-256 hash")
+	}
+	timeoutTime, err := time.Parse(time.RFC3339, timeout)
+	if err != nil {
+		return nil, errors.New("invalid timeout: " + err.Error())
+	}
+	txTime, err := common.GetCheckedTxTime(stub)
+	if err != nil {
+		return nil, err
+	}
+	if !timeoutTime.After(txTime) {
+		return nil, errors.New("timeout " + timeout + " has passed")
+	}
+	if err := recipient.CheckRecipient(); err != nil {
+		return nil, err
+	}
+	if recipient.AssetPoolAddr == sender.AssetPoolAddr {
+		return nil, errors.New("invalid recipient " + recipient.AssetPoolAddr)
+	}
+
+	priData, err := stub.GetTransient()
+	if err != nil {
+		return nil, err
+	}
+	escrowAddr, ok := priData["newAssetAddr"]
+	if !ok {
+		return nil, errors.New("cannot get newAssetAddr data")
+	}
+	if _, err := sender.TransferWithSelection(stub, assetType, cp.AssetPool, amount); err != nil {
+		return nil, err
+	}
+
+	lock := &HashTimeLock{
+		LockID:      stub.GetTxID(),
+		Sender:      sender.AssetPoolAddr,
+		Recipient:   recipient.AssetPoolAddr,
+		AssetTypeID: assetType,
+		Amount:      amount,
+		HashLock:    hashLock,
+		Timeout:     timeout,
+		AssetAddr:   string(escrowAddr),
+		Status:      common.HTLC_STATUS_LOCKED,
+	}
+	if err := lock.Store(stub); err != nil {
+		return nil, err
+	}
+	if err := lock.requireEndorsement(stub, recipient.OwnerMspID); err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
+//接收方在超时前出示原像（十六进制）领取锁定资产，原像随之公开，供另一条链上的对应锁定使用
+func (cp *ContractAssetPool) Claim(stub shim.ChaincodeStubInterface, lock *HashTimeLock, recipient AssetPool, preimage string, newAssetAddr string) error {
+	if err := lock.checkLocked(recipient.AssetPoolAddr, lock.Recipient); err != nil {
+		return err
+	}
+	preimageBytes, err := hex.DecodeString(preimage)
+	if err != nil {
+		return errors.New("preimage must be hex encoded")
+	}
+	hash := sha256.Sum256(preimageBytes)
+	if hex.EncodeToString(hash[:]) != lock.HashLock {
+		return errors.New("preimage does not match hashLock of " + lock.LockID)
+	}
+	expired, err := lock.isExpired(stub)
+	if err != nil {
+		return err
+	}
+	if expired {
+		return errors.New("hash time lock " + lock.LockID + " has expired")
+	}
+
+	if err := cp.releaseTo(stub, lock.AssetAddr, lock.AssetTypeID, lock.Amount, recipient, newAssetAddr); err != nil {
+		return errors.New("claim " + lock.LockID + " failed: " + err.Error())
+	}
+	lock.Preimage = strings.ToLower(preimage)
+	lock.Status = common.HTLC_STATUS_CLAIMED
+	return lock.Store(stub)
+}
+
+//超时后退回发送方
+func (cp *ContractAssetPool) RefundLock(stub shim.ChaincodeStubInterface, lock *HashTimeLock, sender AssetPool, newAssetAddr string) error {
+	if err := lock.checkLocked(sender.AssetPoolAddr, lock.Sender); err != nil {
+		return err
+	}
+	expired, err := lock.isExpired(stub)
+	if err != nil {
+		return err
+	}
+	if !expired {
+		return errors.New("hash time lock " + lock.LockID + " has not expired until " + lock.Timeout)
+	}
+
+	if err := cp.releaseTo(stub, lock.AssetAddr, lock.AssetTypeID, lock.Amount, sender, newAssetAddr); err != nil {
+		return errors.New("refund " + lock.LockID + " failed: " + err.Error())
+	}
+	lock.Status = common.HTLC_STATUS_REFUNDED
+	return lock.Store(stub)
+}
+
+func (lock *HashTimeLock) checkLocked(poolAddr string, expected string) error {
+	if lock.Status != common.HTLC_STATUS_LOCKED {
+		return errors.New("hash time lock " + lock.LockID + " is " + lock.Status)
+	}
+	if poolAddr != expected {
+		return errors.New("asset pool " + poolAddr + " is not a party of hash time lock " + lock.LockID)
+	}
+	return nil
+}
+
+//交易时间晚于超时时间即为超时
+func (lock *HashTimeLock) isExpired(stub shim.ChaincodeStubInterface) (bool, error) {
+	timeout, err := time.Parse(time.RFC3339, lock.Timeout)
+	if err != nil {
+		return false, err
+	}
+	txTime, err := common.GetCheckedTxTime(stub)
+	if err != nil {
+		return false, err
+	}
+	return txTime.After(timeout), nil
+}
+
+//领取、退回都须经接收方机构背书，发送方不能单方面以偏后的时间戳提前退回
+func (lock *HashTimeLock) requireEndorsement(stub shim.ChaincodeStubInterface, mspID string) error {
+	ep, err := statebased.NewStateEP(nil)
+	if err != nil {
+		return err
+	}
+	if err := ep.AddOrgs(statebased.RoleTypePeer, mspID); err != nil {
+		return err
+	}
+	policy, err := ep.Policy()
+	if err != nil {
+		return err
+	}
+	key, err := stub.CreateCompositeKey(common.OBJECT_TYPE_HTLC, []string{lock.LockID})
+	if err != nil {
+		return err
+	}
+	return stub.SetStateValidationParameter(key, policy)
+}
+
+func (lock *HashTimeLock) Store(stub shim.ChaincodeStubInterface) error {
+	err := lock.VerifyFields()
+	if err != nil {
+		return err
+	}
+
+	bytes, err := json.Marshal(lock)
+	if err != nil {
+		return err
+	}
+	key, err := stub.CreateCompositeKey(common.OBJECT_TYPE_HTLC, []string{lock.LockID})
+	if err != nil {
+		return err
+	}
+	return stub.PutState(key, bytes)
+}
+
+func (lock *HashTimeLock) VerifyFields() error {
+	if common.IsEmptyStr(lock.LockID) {
+		return errors.New("lockId is empty")
+	}
+	if common.IsEmptyStr(lock.Sender) {
+		return errors.New("hash time lock's sender is empty")
+	}
+	if common.IsEmptyStr(lock.Recipient) {
+		return errors.New("hash time lock's recipient is empty")
+	}
+	if common.IsEmptyStr(lock.AssetAddr) {
+		return errors.New("hash time lock's assetAddr is empty")
+	}
+	if common.IsEmptyStr(lock.HashLock) {
+		return errors.New("hash time lock's hashLock is empty")
+	}
+	return nil
+}
+
+func GetHashTimeLock(stub shim.ChaincodeStubInterface, lockID string) (*HashTimeLock, error) {
+	lock := &HashTimeLock{}
+	if err := common.GetDataByKey(stub, common.OBJECT_TYPE_HTLC, []string{lockID}, lock); err != nil {
+		return nil, errors.New("get hash time lock " + lockID + " failed: " + err.Error())
+	}
+	return lock, nil
+}
@@ -0,0 +1,55 @@
+package assetPool
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/FabricTransaction/common"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+//资产池最近一次已使用的请求序号，用于防止签名请求被重放
+type PoolNonce struct {
+	AssetPoolAddr string `json:"assetPoolAddr"`
+	Nonce         uint64 `json:"nonce"` //已使用的最大序号
+	TxID          string `json:"txId"`  //使用该序号的交易ID
+}
+
+func GetPoolNonce(stub shim.ChaincodeStubInterface, poolAddr string) (*PoolNonce, error) {
+	exist, _, val, err := common.CheckExistByKey(stub, common.OBJECT_TYPE_POOL_NONCE, []string{poolAddr})
+	if err != nil {
+		return nil, err
+	}
+	nonce := &PoolNonce{AssetPoolAddr: poolAddr}
+	if !exist {
+		return nonce, nil
+	}
+	if err := json.Unmarshal(val, nonce); err != nil {
+		return nil, err
+	}
+	return nonce, nil
+}
+
+//序号须大于已使用的最大序号，使用后记录为新的最大序号
+func (pool *AssetPool) UseNonce(stub shim.ChaincodeStubInterface, nonce uint64) error {
+	last, err := GetPoolNonce(stub, pool.AssetPoolAddr)
+	if err != nil {
+		return err
+	}
+	if nonce <= last.Nonce {
+		return errors.New("stale nonce " + strconv.FormatUint(nonce, 10) + " for asset pool " + pool.AssetPoolAddr + ", last used is " + strconv.FormatUint(last.Nonce, 10))
+	}
+	last.Nonce = nonce
+	last.TxID = stub.GetTxID()
+
+	bytes, err := json.Marshal(last)
+	if err != nil {
+		return err
+	}
+	key, err := stub.CreateCompositeKey(common.OBJECT_TYPE_POOL_NONCE, []string{pool.AssetPoolAddr})
+	if err != nil {
+		return err
+	}
+	return stub.PutState(key, bytes)
+}
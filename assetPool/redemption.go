@@ -0,0 +1,95 @@
+package assetPool
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	ast "github.com/FabricTransaction/asset"
+	"github.com/FabricTransaction/common"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+//赎回记录
+type Redemption struct {
+	TxID          string        `json:"txId"`
+	AssetPoolAddr string        `json:"assetPoolAddr"` //赎回资产池ID
+	MspID         string        `json:"mspId"`         //发起赎回的机构
+	AssetTypeID   string        `json:"assetTypeId"`
+	Amount        common.Amount `json:"amount"`
+	RedeemTime    string        `json:"redeemTime"`
+}
+
+//赎回本资产池的资产：消耗资产但不产生接收方输出，并减少该资产类型的流通量
+func (pool *AssetPool) Redeem(stub shim.ChaincodeStubInterface, assetType string, _value common.Amount) error {
+	info, err := ast.GetAssetInfoByID(stub, assetType)
+	if err != nil {
+		return err
+	}
+	if err := info.Retire(stub, _value); err != nil {
+		return err
+	}
+	if _, err := pool.SpendAssets(stub, assetType, _value, nil); err != nil {
+		return err
+	}
+
+	mspID, err := common.GetMspID(stub)
+	if err != nil {
+		return err
+	}
+	txTime, err := common.GetTxTime(stub)
+	if err != nil {
+		return err
+	}
+	redemption := Redemption{
+		TxID:          stub.GetTxID(),
+		AssetPoolAddr: pool.AssetPoolAddr,
+		MspID:         mspID,
+		AssetTypeID:   assetType,
+		Amount:        _value,
+		RedeemTime:    txTime.Format(time.RFC3339Nano),
+	}
+	if err := redemption.Store(stub); err != nil {
+		return err
+	}
+
+	return pool.SetRedeemEvent(stub, assetType, _value)
+}
+
+func (redemption *Redemption) Store(stub shim.ChaincodeStubInterface) error {
+	if common.IsEmptyStr(redemption.TxID) || common.IsEmptyStr(redemption.AssetTypeID) {
+		return errors.New("redemption's txId or assetTypeId is empty")
+	}
+
+	bytes, err := json.Marshal(redemption)
+	if err != nil {
+		return err
+	}
+	key, err := stub.CreateCompositeKey(common.OBJECT_TYPE_REDEMPTION, []string{redemption.AssetTypeID, redemption.TxID})
+	if err != nil {
+		return err
+	}
+	return stub.PutState(key, bytes)
+}
+
+func GetRedemptionsByAssetType(stub shim.ChaincodeStubInterface, assetType string) ([]Redemption, error) {
+	iter, err := stub.GetStateByPartialCompositeKey(common.OBJECT_TYPE_REDEMPTION, []string{assetType})
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	redemptions := []Redemption{}
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		var redemption Redemption
+		if err := json.Unmarshal(kv.Value, &redemption); err != nil {
+			return nil, err
+		}
+		redemptions = append(redemptions, redemption)
+	}
+	return redemptions, nil
+}
@@ -0,0 +1,72 @@
+package FabricTransaction
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/FabricTransaction/asset"
+	"github.com/FabricTransaction/assetPool"
+	"github.com/FabricTransaction/common"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+//args: assetPoolId, assetTypeId
+//transient: inputs或assetAddrs/encryptedAddrs/blindings，即client.DecryptAssetAddrs解密得到的资产
+func balanceOfHandler(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) < 2 {
+		return nil, errors.New("need assetPoolId and assetTypeId")
+	}
+	var pool assetPool.AssetPool
+	if err := common.GetDataByKey(stub, common.OBJECT_TYPE_ASEETPOOL, []string{args[0]}, &pool); err != nil {
+		return nil, err
+	}
+	balance, err := pool.BalanceOf(stub, args[1])
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(balance)
+}
+
+//args: assetPoolId, pageSize, bookmark, [assetTypeId], [hasTransfered: true/false]
+func listAssetAddrsHandler(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) < 3 {
+		return nil, errors.New("need assetPoolId, pageSize and bookmark")
+	}
+	pageSize, err := strconv.ParseInt(args[1], 10, 32)
+	if err != nil || pageSize <= 0 {
+		return nil, errors.New("invalid pageSize: " + args[1])
+	}
+
+	assetType := ""
+	if len(args) > 3 {
+		assetType = args[3]
+	}
+	var hasTransfered *bool
+	if len(args) > 4 && !common.IsEmptyStr(args[4]) {
+		v, err := strconv.ParseBool(args[4])
+		if err != nil {
+			return nil, errors.New("invalid hasTransfered: " + args[4])
+		}
+		hasTransfered = &v
+	}
+
+	page, err := assetPool.ListAssetAddrs(stub, args[0], assetType, hasTransfered, int32(pageSize), args[2])
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(page)
+}
+
+//args: assetAddr
+//资产接收方据此取回转入的资产，用client.VerifyAssetOwnership校验归属
+func queryAssetHandler(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) < 1 {
+		return nil, errors.New("no asset addr")
+	}
+	assets, err := asset.GetAssetsByAddrs(stub, []string{args[0]})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal((*assets)[0])
+}
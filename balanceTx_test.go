@@ -0,0 +1,58 @@
+package FabricTransaction
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/FabricTransaction/assetPool"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func (s *testStub) balanceOf(t *testing.T, pool *testPool, assetType string, inputs []assetPool.SpendInput) string {
+	t.Helper()
+	bytes, _ := json.Marshal(inputs)
+	res := s.invoke(pool.mspID, map[string][]byte{"inputs": bytes}, "balanceOf", pool.addr, assetType)
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	var balance assetPool.Balance
+	if err := json.Unmarshal(res.Payload, &balance); err != nil {
+		t.Fatal(err)
+	}
+	return balance.Balance.String()
+}
+
+//只统计属于本资产池、类型相符且未花费的资产，盲化因子不符的输入不计入
+func TestBalanceOf(t *testing.T) {
+	stub := newTestStub(t)
+	poolA := stub.addPool(t, "Org1MSP", "poolA")
+	poolB := stub.addPool(t, "Org2MSP", "poolB")
+	stub.issue(t, poolA, "CNY", "100", "a1")
+	stub.issue(t, poolA, "CNY", "40", "a2")
+	if res := stub.transfer(t, poolA, poolB, "30", stub.transferTransient(t, poolA, []string{"a1"}, "b1", "a3")); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	stub.issue(t, poolB, "BOND", "7", "bond1")
+
+	walletA := stub.wallet(t, poolA)
+	walletB := stub.wallet(t, poolB)
+	inputs := []assetPool.SpendInput{walletA["a1"], walletA["a2"], walletA["a3"], walletB["b1"], walletB["bond1"]}
+	if got := stub.balanceOf(t, poolA, "CNY", inputs); got != "110" {
+		t.Errorf("poolA CNY balance = %s, want 110", got)
+	}
+	if got := stub.balanceOf(t, poolB, "CNY", inputs); got != "30" {
+		t.Errorf("poolB CNY balance = %s, want 30", got)
+	}
+	if got := stub.balanceOf(t, poolB, "BOND", inputs); got != "7" {
+		t.Errorf("poolB BOND balance = %s, want 7", got)
+	}
+
+	tampered := walletA["a3"]
+	tampered.Blinding = walletA["a2"].Blinding
+	if got := stub.balanceOf(t, poolA, "CNY", []assetPool.SpendInput{walletA["a2"], tampered}); got != "40" {
+		t.Errorf("poolA CNY balance with wrong blinding = %s, want 40", got)
+	}
+	if got := stub.balanceOf(t, poolA, "CNY", []assetPool.SpendInput{}); got != "0" {
+		t.Errorf("poolA CNY balance without inputs = %s, want 0", got)
+	}
+}
@@ -0,0 +1,67 @@
+package FabricTransaction
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/FabricTransaction/assetPool"
+	"github.com/FabricTransaction/common"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+type PayoutReq struct {
+	ToPool string        `json:"toPool"` //转入资产池ID
+	Amount common.Amount `json:"amount"` //转让量
+}
+
+type BatchTransferReq struct {
+	FromPool    string      `json:"fromPool"`    //转出资产池ID
+	AssetTypeID string      `json:"assetTypeId"` //资产类型
+	Payouts     []PayoutReq `json:"payouts"`
+	SignStruct
+}
+
+//transient: inputs或assetAddrs/encryptedAddrs/blindings、changeAddr、[selectStrategy]
+//newAssetAddrs为各接收方的新资产地址，与payouts按下标对应
+func batchTransferHandler(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	req := BatchTransferReq{}
+	if err := json.Unmarshal([]byte(args[0]), &req); err != nil {
+		return nil, err
+	}
+
+	priData, err := stub.GetTransient()
+	if err != nil {
+		return nil, err
+	}
+	addrsBytes, ok := priData["newAssetAddrs"]
+	if !ok {
+		return nil, errors.New("cannot get newAssetAddrs data")
+	}
+	var newAssetAddrs []string
+	if err := json.Unmarshal(addrsBytes, &newAssetAddrs); err != nil {
+		return nil, err
+	}
+	if len(newAssetAddrs) != len(req.Payouts) {
+		return nil, errors.New("need " + strconv.Itoa(len(req.Payouts)) + " newAssetAddrs, got " + strconv.Itoa(len(newAssetAddrs)))
+	}
+
+	var from assetPool.AssetPool
+	if err := common.GetDataByKey(stub, common.OBJECT_TYPE_ASEETPOOL, []string{req.FromPool}, &from); err != nil {
+		return nil, err
+	}
+	entries := make([]assetPool.BatchTransferEntry, 0, len(req.Payouts))
+	for i, v := range req.Payouts {
+		entry := assetPool.BatchTransferEntry{Amount: v.Amount, NewAssetAddr: newAssetAddrs[i]}
+		if err := common.GetDataByKey(stub, common.OBJECT_TYPE_ASEETPOOL, []string{v.ToPool}, &entry.To); err != nil {
+			return nil, errors.New("get asset pool " + v.ToPool + " failed: " + err.Error())
+		}
+		entries = append(entries, entry)
+	}
+
+	selection, err := from.BatchTransfer(stub, req.AssetTypeID, entries)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(selection)
+}
@@ -0,0 +1,221 @@
+package FabricTransaction
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/FabricTransaction/asset"
+	"github.com/FabricTransaction/assetPool"
+	"github.com/FabricTransaction/common"
+	"github.com/FabricTransaction/orgManage"
+	"github.com/FabricTransaction/txLog"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+type FabricTransactionChaincode struct {
+}
+
+type InitReq struct {
+	AdminOrgs  []orgManage.Org   `json:"adminOrgs"`  //管理员机构
+	AssetTypes []asset.AssetInfo `json:"assetTypes"` //默认资产类型
+}
+
+type handlerFunc func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error)
+
+type route struct {
+	handler   handlerFunc
+	signer    string //args[0]中签名资产池ID所在的字段名，为空则不验签
+	multiSign bool   //由多个资产池签名，在处理函数中验签
+	admin     bool   //仅管理员机构可调用
+}
+
+var routes map[string]route
+
+func init() {
+	routes = map[string]route{
+		"issue":            {handler: issueHandler, signer: "toPool"},
+		"transfer":         {handler: transferHandler, signer: "fromPool"},
+		"multiTransfer":    {handler: multiTransferHandler, multiSign: true}, //由全部转出资产池签名
+		"batchTransfer":    {handler: batchTransferHandler, signer: "fromPool"},
+		"approve":          {handler: approveHandler, signer: "fromPool"},
+		"transferFrom":     {handler: transferFromHandler, signer: "spender"},
+		"allowance":        {handler: allowanceHandler},
+		"listAsset":        {handler: listAssetHandler, signer: "seller"},
+		"buyListing":       {handler: buyListingHandler, signer: "buyer"},
+		"cancelListing":    {handler: cancelListingHandler, signer: "seller"},
+		"queryListing":     {handler: queryListingHandler},
+		"lockHTLC":         {handler: lockHTLCHandler, signer: "sender"},
+		"claimHTLC":        {handler: claimHTLCHandler, signer: "recipient"},
+		"refundHTLC":       {handler: refundHTLCHandler, signer: "sender"},
+		"queryHTLC":        {handler: queryHTLCHandler},
+		"redeem":           {handler: redeemHandler, signer: "fromPool"},
+		"queryRedemptions": {handler: queryRedemptionsHandler},
+		"balanceOf":        {handler: balanceOfHandler},
+		"listAssetAddrs":   {handler: listAssetAddrsHandler},
+		"queryAsset":       {handler: queryAssetHandler},
+		"queryAccountLog":  {handler: queryAccountLogHandler},
+		"queryChainLog":    {handler: queryChainLogHandler},
+		"addAssetPool":     {handler: addAssetPoolHandler},
+		"addAssetType":     {handler: addAssetTypeHandler, admin: true},
+		"queryAssetPool":   {handler: queryAssetPoolHandler},
+		"queryAssetType":   {handler: queryAssetTypeHandler},
+		"addOrg":           {handler: addOrgHandler, admin: true},
+		"updateOrg":        {handler: updateOrgHandler},
+		"disableOrg":       {handler: disableOrgHandler, admin: true},
+		"queryOrg":         {handler: queryOrgHandler},
+	}
+}
+
+func (cc *FabricTransactionChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	if err := new(assetPool.ContractAssetPool).Init(stub); err != nil {
+		return shim.Error("[init] " + err.Error())
+	}
+
+	_, args := stub.GetFunctionAndParameters()
+	if len(args) < 1 {
+		return shim.Success(nil)
+	}
+
+	req := InitReq{}
+	if err := json.Unmarshal([]byte(args[0]), &req); err != nil {
+		return shim.Error("[init] " + err.Error())
+	}
+	//升级链码时会以相同参数再次调用Init，已登记的机构和资产类型保持不变
+	for _, v := range req.AdminOrgs {
+		exist, _, _, err := common.CheckExistByKey(stub, common.OBJECT_TYPE_ORG, []string{v.MspID})
+		if err != nil {
+			return shim.Error("[init] " + err.Error())
+		}
+		if exist {
+			continue
+		}
+		v.IsAdmin = true
+		org := new(orgManage.Org)
+		if err := org.Init(stub, v); err != nil {
+			return shim.Error("[init] " + err.Error())
+		}
+	}
+	for _, v := range req.AssetTypes {
+		exist, _, _, err := common.CheckExistByKey(stub, common.OBJECT_TYPE_ASSET_INFO, []string{v.AssetTypeID})
+		if err != nil {
+			return shim.Error("[init] " + err.Error())
+		}
+		if exist {
+			continue
+		}
+		info := new(asset.AssetInfo)
+		if err := info.Init(stub, v); err != nil {
+			return shim.Error("[init] " + err.Error())
+		}
+	}
+	return shim.Success(nil)
+}
+
+func (cc *FabricTransactionChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	fn, args := stub.GetFunctionAndParameters()
+	return dispatch(stub, fn, args)
+}
+
+func dispatch(stub shim.ChaincodeStubInterface, fn string, args []string) pb.Response {
+	r, ok := routes[fn]
+	if !ok {
+		return shim.Error("unknown function: " + fn)
+	}
+
+	if r.admin {
+		if err := orgManage.CheckAdminOrg(stub); err != nil {
+			return shim.Error("[" + fn + "] " + err.Error())
+		}
+	}
+	if !common.IsEmptyStr(r.signer) || r.multiSign {
+		if len(args) < 1 {
+			return shim.Error("[" + fn + "] no request")
+		}
+		if err := checkSignedFunction(args[0], fn); err != nil {
+			return shim.Error("[" + fn + "] " + err.Error())
+		}
+	}
+	if !common.IsEmptyStr(r.signer) {
+		if err := VerifyReq(stub, args[0], r.signer); err != nil {
+			return shim.Error("[" + fn + "] " + err.Error())
+		}
+	}
+
+	es := common.NewEventStub(stub)
+	payload, err := r.handler(es, args)
+	if err != nil {
+		return shim.Error("[" + fn + "] " + err.Error())
+	}
+	if err := txLog.WriteTxLogs(es, es.Events()); err != nil {
+		return shim.Error("[" + fn + "] " + err.Error())
+	}
+	if err := es.Flush(); err != nil {
+		return shim.Error("[" + fn + "] " + err.Error())
+	}
+	return shim.Success(payload)
+}
+
+func issueHandler(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	return absTxHandler(stub, args, common.TX_TYPE_ISSUE)
+}
+
+//transient: inputs或assetAddrs/encryptedAddrs/blindings、newAssetAddr、changeAddr、[selectStrategy]
+func transferHandler(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	return absTxHandler(stub, args, common.TX_TYPE_TRANSFER)
+}
+
+func absTxHandler(stub shim.ChaincodeStubInterface, args []string, txType string) ([]byte, error) {
+	tx := TransferReq{}
+	if err := json.Unmarshal([]byte(args[0]), &tx); err != nil {
+		return nil, err
+	}
+	if tx.TxType != txType {
+		return nil, errors.New("txType mismatch: " + tx.TxType)
+	}
+	return doAbsTx(stub, tx)
+}
+
+func addAssetPoolHandler(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) < 1 {
+		return nil, errors.New("no asset pool")
+	}
+	pool := AssetPoolReq{}
+	if err := json.Unmarshal([]byte(args[0]), &pool); err != nil {
+		return nil, err
+	}
+	return nil, AddAssetPool(stub, pool)
+}
+
+func addAssetTypeHandler(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) < 1 {
+		return nil, errors.New("no asset type")
+	}
+	info := asset.AssetInfo{}
+	if err := json.Unmarshal([]byte(args[0]), &info); err != nil {
+		return nil, err
+	}
+	return nil, new(asset.AssetInfo).Init(stub, info)
+}
+
+func queryAssetPoolHandler(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) < 1 {
+		return nil, errors.New("no asset pool id")
+	}
+	pool := assetPool.AssetPool{}
+	if err := common.GetDataByKey(stub, common.OBJECT_TYPE_ASEETPOOL, []string{args[0]}, &pool); err != nil {
+		return nil, err
+	}
+	return json.Marshal(pool)
+}
+
+func queryAssetTypeHandler(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) < 1 {
+		return nil, errors.New("no asset type id")
+	}
+	info := asset.AssetInfo{}
+	if err := common.GetDataByKey(stub, common.OBJECT_TYPE_ASSET_INFO, []string{args[0]}, &info); err != nil {
+		return nil, err
+	}
+	return json.Marshal(info)
+}
@@ -0,0 +1,911 @@
+package FabricTransaction
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FabricTransaction/asset"
+	"github.com/FabricTransaction/assetPool"
+	"github.com/FabricTransaction/client"
+	"github.com/FabricTransaction/common"
+	"github.com/FabricTransaction/orgManage"
+	"github.com/FabricTransaction/txLog"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/core/chaincode/shim/ext/statebased"
+	"github.com/hyperledger/fabric/protos/ledger/queryresult"
+	"github.com/hyperledger/fabric/protos/msp"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+const testExpireTime = "2099-01-01T00:00:00Z"
+
+//MockStub不提供调用者身份、transient数据和分页查询，由testStub补充
+type testStub struct {
+	*shim.MockStub
+	creator   []byte
+	transient map[string][]byte
+	events    [][]byte
+	txCount   int
+	txTime    int64 //非零时作为交易时间戳（秒）
+}
+
+func (s *testStub) GetCreator() ([]byte, error) {
+	return s.creator, nil
+}
+
+func (s *testStub) GetTransient() (map[string][]byte, error) {
+	return s.transient, nil
+}
+
+type kvIter struct {
+	kvs []*queryresult.KV
+}
+
+func (it *kvIter) HasNext() bool { return len(it.kvs) > 0 }
+func (it *kvIter) Close() error  { return nil }
+func (it *kvIter) Next() (*queryresult.KV, error) {
+	kv := it.kvs[0]
+	it.kvs = it.kvs[1:]
+	return kv, nil
+}
+
+//按键序分页，书签为下一页第一条记录的键
+func (s *testStub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	iter, err := s.GetStateByPartialCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer iter.Close()
+
+	page := &kvIter{}
+	next := ""
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, nil, err
+		}
+		if kv.Key < bookmark {
+			continue
+		}
+		if int32(len(page.kvs)) == pageSize {
+			next = kv.Key
+			break
+		}
+		page.kvs = append(page.kvs, kv)
+	}
+	return page, &pb.QueryResponseMetadata{FetchedRecordsCount: int32(len(page.kvs)), Bookmark: next}, nil
+}
+
+//MockStub的事件通道容量有限，测试中只保留事件
+func (s *testStub) SetEvent(name string, payload []byte) error {
+	s.events = append(s.events, payload)
+	return nil
+}
+
+type testPool struct {
+	addr      string
+	mspID     string
+	key       *ecdsa.PrivateKey
+	publicKey string
+	nonce     uint64
+}
+
+//各机构的验签密钥，同一测试进程内保持不变
+var testOrgKeys = map[string]*ecdsa.PrivateKey{}
+
+func testOrgKey(mspID string) *ecdsa.PrivateKey {
+	if key, ok := testOrgKeys[mspID]; ok {
+		return key
+	}
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	testOrgKeys[mspID] = key
+	return key
+}
+
+func encodePublicKey(key *ecdsa.PrivateKey) string {
+	pubBytes, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	return base64.StdEncoding.EncodeToString(pubBytes)
+}
+
+func testInitArgs() []byte {
+	total, _ := common.NewAmount("1000")
+	req := InitReq{
+		AdminOrgs: []orgManage.Org{
+			{MspID: "Org1MSP", OrgName: "org1", PublicKey: encodePublicKey(testOrgKey("Org1MSP"))},
+			{MspID: "Org2MSP", OrgName: "org2", PublicKey: encodePublicKey(testOrgKey("Org2MSP"))},
+		},
+		AssetTypes: []asset.AssetInfo{
+			{AssetTypeID: "CNY", AssetName: "yuan", AssetSymbol: "CNY", Decimals: 2, TotalSupply: total, IssuerMspID: "Org1MSP"},
+			{AssetTypeID: "BOND", AssetName: "bond", AssetSymbol: "BOND", Decimals: 0, TotalSupply: total, IssuerMspID: "Org2MSP"},
+		},
+	}
+	bytes, _ := json.Marshal(req)
+	return bytes
+}
+
+func newTestStub(t *testing.T) *testStub {
+	stub := &testStub{MockStub: shim.NewMockStub("FabricTransaction", new(FabricTransactionChaincode))}
+	if res := stub.MockInit("init", [][]byte{[]byte("init"), testInitArgs()}); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	return stub
+}
+
+func (s *testStub) invoke(mspID string, transient map[string][]byte, fn string, args ...string) pb.Response {
+	s.creator, _ = proto.Marshal(&msp.SerializedIdentity{Mspid: mspID})
+	s.transient = transient
+	s.txCount++
+	txID := "tx" + strconv.Itoa(s.txCount)
+	s.MockTransactionStart(txID)
+	if s.txTime != 0 {
+		s.TxTimestamp = &timestamp.Timestamp{Seconds: s.txTime}
+	}
+	defer s.MockTransactionEnd(txID)
+	return dispatch(s, fn, args)
+}
+
+func (s *testStub) addPool(t *testing.T, mspID string, addr string) *testPool {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := &testPool{addr: addr, mspID: mspID, key: key, publicKey: encodePublicKey(key)}
+
+	req := AssetPoolReq{AssetPool: assetPool.AssetPool{AssetPoolAddr: addr, AssetPoolType: "personal", PublicKey: pool.publicKey}}
+	bytes, _ := json.Marshal(req)
+	if res := s.invoke(mspID, nil, "addAssetPool", string(bytes)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	return pool
+}
+
+//由资产池签名提交给fn的请求，transient摘要一并签入，signStruct为req中内嵌的SignStruct
+//transient中没有加密随机种子时补充一个
+func (pool *testPool) signReq(t *testing.T, fn string, req interface{}, signStruct *SignStruct, transient map[string][]byte) string {
+	addEncryptSeed(t, transient)
+	pool.nonce++
+	hashes, err := client.HashTransient(transient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signStruct.Function = fn
+	signStruct.Nonce = pool.nonce
+	signStruct.ExpireTime = testExpireTime
+	signStruct.TransientHashes = hashes
+	signed, err := client.SignRequest(pool.key, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func addEncryptSeed(t *testing.T, transient map[string][]byte) {
+	if transient == nil {
+		return
+	}
+	if _, ok := transient["encryptSeed"]; ok {
+		return
+	}
+	seed, err := client.NewEncryptSeed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	transient["encryptSeed"] = seed
+}
+
+//发行请求提交给issue，转账请求提交给transfer
+func (pool *testPool) sign(t *testing.T, req *TransferReq, transient map[string][]byte) string {
+	fn := "transfer"
+	if req.TxType == common.TX_TYPE_ISSUE {
+		fn = "issue"
+	}
+	return pool.signReq(t, fn, req, &req.SignStruct, transient)
+}
+
+//资产池解密链上登记的加密资产地址，得到明文地址到花费输入的映射
+func (s *testStub) wallet(t *testing.T, pool *testPool) map[string]assetPool.SpendInput {
+	iter, err := s.MockStub.GetStateByPartialCompositeKey(common.OBJECT_TYPE_ASSET_ADDR, []string{pool.addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iter.Close()
+	var addrs []assetPool.AssetAddr
+	for iter.HasNext() {
+		kv, _ := iter.Next()
+		var addr assetPool.AssetAddr
+		json.Unmarshal(kv.Value, &addr)
+		addrs = append(addrs, addr)
+	}
+	wallet, err := client.DecryptAssetAddrs(pool.key, addrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return wallet
+}
+
+func (s *testStub) transferTransient(t *testing.T, from *testPool, inputs []string, newAssetAddr string, changeAddr string) map[string][]byte {
+	wallet := s.wallet(t, from)
+	encrypted := make([]string, len(inputs))
+	blindings := make([]string, len(inputs))
+	for i, v := range inputs {
+		encrypted[i] = wallet[v].EncryptedAddr
+		blindings[i] = wallet[v].Blinding
+	}
+	addrs, _ := json.Marshal(inputs)
+	encryptedAddrs, _ := json.Marshal(encrypted)
+	blindingsBytes, _ := json.Marshal(blindings)
+	transient := map[string][]byte{
+		"assetAddrs":     addrs,
+		"encryptedAddrs": encryptedAddrs,
+		"blindings":      blindingsBytes,
+		"newAssetAddr":   []byte(newAssetAddr),
+	}
+	if changeAddr != "" {
+		transient["changeAddr"] = []byte(changeAddr)
+	}
+	return transient
+}
+
+func (s *testStub) transfer(t *testing.T, from *testPool, to *testPool, amount string, transient map[string][]byte) pb.Response {
+	value, _ := common.NewAmount(amount)
+	req := &TransferReq{FromPool: from.addr, ToPool: to.addr, Amount: value, TxType: common.TX_TYPE_TRANSFER, AssetTypeID: "CNY"}
+	return s.invoke(from.mspID, transient, "transfer", from.sign(t, req, transient))
+}
+
+func (s *testStub) issue(t *testing.T, pool *testPool, assetType string, amount string, addr string) {
+	value, _ := common.NewAmount(amount)
+	transient := map[string][]byte{"assetAddr": []byte(addr)}
+	req := &TransferReq{ToPool: pool.addr, Amount: value, TxType: common.TX_TYPE_ISSUE, AssetTypeID: assetType}
+	if res := s.invoke(pool.mspID, transient, "issue", pool.sign(t, req, transient)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+}
+
+func (s *testStub) queryAsset(t *testing.T, addr string) asset.Asset {
+	res := s.invoke("Org1MSP", nil, "queryAsset", addr)
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	var a asset.Asset
+	if err := json.Unmarshal(res.Payload, &a); err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+//用资产池解密得到的盲化因子校验资产归属
+func (s *testStub) verifyOwnership(t *testing.T, a asset.Asset, pool *testPool) error {
+	input := s.wallet(t, pool)[a.AssetAddr]
+	return client.VerifyAssetOwnership(a, pool.addr, pool.publicKey, input.EncryptedAddr, input.Blinding)
+}
+
+//升级链码时以相同参数再次Init，已登记的机构与资产类型保持不变
+func TestInitIdempotent(t *testing.T) {
+	stub := newTestStub(t)
+	poolA := stub.addPool(t, "Org1MSP", "poolA")
+	stub.issue(t, poolA, "CNY", "100", "a1")
+	if res := stub.invoke("Org1MSP", nil, "disableOrg", "Org2MSP"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	if res := stub.MockInit("upgrade", [][]byte{[]byte("init"), testInitArgs()}); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	res := stub.invoke("Org1MSP", nil, "queryOrg", "Org2MSP")
+	var org orgManage.Org
+	json.Unmarshal(res.Payload, &org)
+	if org.IsEnabled() {
+		t.Error("re-init enabled a disabled org")
+	}
+	res = stub.invoke("Org1MSP", nil, "queryAssetType", "CNY")
+	var info asset.AssetInfo
+	json.Unmarshal(res.Payload, &info)
+	if info.Circulating.String() != "100" {
+		t.Errorf("circulating = %s after re-init, want 100", info.Circulating)
+	}
+}
+
+func TestCrossOrgTransferChain(t *testing.T) {
+	stub := newTestStub(t)
+	poolA := stub.addPool(t, "Org1MSP", "poolA")
+	poolB := stub.addPool(t, "Org2MSP", "poolB")
+	poolC := stub.addPool(t, "Org1MSP", "poolC")
+
+	amount, _ := common.NewAmount("100")
+	issueTransient := map[string][]byte{"assetAddr": []byte("a1")}
+	issueReq := &TransferReq{ToPool: poolA.addr, Amount: amount, TxType: common.TX_TYPE_ISSUE, AssetTypeID: "CNY"}
+	if res := stub.invoke("Org1MSP", issueTransient, "issue", poolA.sign(t, issueReq, issueTransient)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	//Org1提交，为Org2的资产池B生成资产
+	res := stub.transfer(t, poolA, poolB, "30", stub.transferTransient(t, poolA, []string{"a1"}, "b1", "a2"))
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	var selection assetPool.Selection
+	json.Unmarshal(res.Payload, &selection)
+	if len(selection.Inputs) != 1 || selection.Inputs[0].AssetAddr != "a1" || selection.Change.String() != "70" {
+		t.Errorf("selection = %+v", selection)
+	}
+	if _, ok := stub.wallet(t, poolB)["b1"]; !ok {
+		t.Fatal("poolB cannot rediscover b1")
+	}
+	b1 := stub.queryAsset(t, "b1")
+	if err := stub.verifyOwnership(t, b1, poolB); err != nil {
+		t.Fatal(err)
+	}
+	b1Input := stub.wallet(t, poolB)["b1"]
+	if err := client.VerifyAssetOwnership(b1, poolA.addr, poolA.publicKey, b1Input.EncryptedAddr, b1Input.Blinding); err == nil {
+		t.Error("b1 should not verify for poolA")
+	}
+	//没有盲化因子无法由公开信息判断资产归属
+	if err := client.VerifyAssetOwnership(b1, poolB.addr, poolB.publicKey, b1Input.EncryptedAddr, ""); err == nil {
+		t.Error("b1 should not verify without blinding")
+	}
+
+	//Org2花费Org1为其生成的资产
+	if res := stub.transfer(t, poolB, poolC, "10", stub.transferTransient(t, poolB, []string{"b1"}, "c1", "b2")); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	//Org1再花费Org2为其生成的资产
+	if res := stub.transfer(t, poolC, poolA, "10", stub.transferTransient(t, poolC, []string{"c1"}, "a3", "")); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if err := stub.verifyOwnership(t, stub.queryAsset(t, "c1"), poolC); err == nil {
+		t.Error("spent c1 should not verify")
+	}
+	for addr, pool := range map[string]*testPool{"a2": poolA, "a3": poolA, "b2": poolB} {
+		if err := stub.verifyOwnership(t, stub.queryAsset(t, addr), pool); err != nil {
+			t.Errorf("%s: %v", addr, err)
+		}
+	}
+
+	walletB := stub.wallet(t, poolB)
+	balanceInputs, _ := json.Marshal([]assetPool.SpendInput{walletB["b1"], walletB["b2"]})
+	balanceTransient := map[string][]byte{"inputs": balanceInputs}
+	res = stub.invoke("Org2MSP", balanceTransient, "balanceOf", poolB.addr, "CNY")
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	var balance assetPool.Balance
+	json.Unmarshal(res.Payload, &balance)
+	if balance.Balance.String() != "20" {
+		t.Errorf("poolB balance = %s, want 20", balance.Balance)
+	}
+}
+
+//账户流水明文中只有流水ID与资产池ID，交易ID、对方资产池与金额须用资产池私钥解密
+func TestAccountLogSealed(t *testing.T) {
+	stub := newTestStub(t)
+	poolA := stub.addPool(t, "Org1MSP", "poolA")
+	poolB := stub.addPool(t, "Org2MSP", "poolB")
+	stub.issue(t, poolA, "CNY", "100", "a1")
+	if res := stub.transfer(t, poolA, poolB, "30", stub.transferTransient(t, poolA, []string{"a1"}, "b1", "a2")); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	txID := "tx" + strconv.Itoa(stub.txCount)
+
+	iter, err := stub.GetStateByPartialCompositeKey(common.OBJECT_TYPE_LOG_ADDR, []string{poolB.addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var logs []txLog.AccountLog
+	for iter.HasNext() {
+		kv, _ := iter.Next()
+		for _, secret := range []string{txID, poolA.addr, "CNY", "30"} {
+			if strings.Contains(string(kv.Value), `"`+secret+`"`) {
+				t.Errorf("account log leaks %q: %s", secret, kv.Value)
+			}
+		}
+		var accountLog txLog.AccountLog
+		json.Unmarshal(kv.Value, &accountLog)
+		logs = append(logs, accountLog)
+	}
+	iter.Close()
+	details, err := client.DecryptStatement(poolB.key, logs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(details) != 1 || details[0].TxID != txID || details[0].Counterparty != poolA.addr || details[0].Amount.String() != "30" {
+		t.Errorf("poolB statement = %+v", details)
+	}
+	if _, err := client.DecryptStatement(poolA.key, logs); err == nil {
+		t.Error("poolA decrypted poolB's statement")
+	}
+}
+
+//每个成功的调用发出一个事件，包含本次调用的全部交易记录；被拒绝的调用不发出事件
+func TestTxEvents(t *testing.T) {
+	stub := newTestStub(t)
+	poolA := stub.addPool(t, "Org1MSP", "poolA")
+	poolB := stub.addPool(t, "Org2MSP", "poolB")
+	poolC := stub.addPool(t, "Org2MSP", "poolC")
+
+	checkEvent := func(name string, want ...common.TxEvent) {
+		t.Helper()
+		if len(stub.events) != 1 {
+			t.Fatalf("%s emitted %d events, want 1", name, len(stub.events))
+		}
+		for _, addr := range []string{"a1", "a2", "b1", "c1"} {
+			if strings.Contains(string(stub.events[0]), `"`+addr+`"`) {
+				t.Errorf("%s event leaks asset addr %s: %s", name, addr, stub.events[0])
+			}
+		}
+		var payload common.TxEventPayload
+		if err := json.Unmarshal(stub.events[0], &payload); err != nil {
+			t.Fatal(err)
+		}
+		if payload.Version != common.EVENT_VERSION || payload.TxID != "tx"+strconv.Itoa(stub.txCount) || len(payload.Events) != len(want) {
+			t.Fatalf("%s event = %+v", name, payload)
+		}
+		for i, w := range want {
+			got := payload.Events[i]
+			if got.TxType != w.TxType || got.AssetTypeID != w.AssetTypeID || got.FromPool != w.FromPool || got.ToPool != w.ToPool || got.Amount.Cmp(w.Amount) != 0 {
+				t.Errorf("%s record %d = %+v, want %+v", name, i, got, w)
+			}
+		}
+		stub.events = nil
+	}
+
+	stub.events = nil
+	stub.issue(t, poolA, "CNY", "100", "a1")
+	checkEvent("issue", common.TxEvent{TxType: common.TX_TYPE_ISSUE, AssetTypeID: "CNY", ToPool: poolA.addr, Amount: common.NewAmountFromInt(100)})
+
+	approve := &ApproveReq{FromPool: poolA.addr, Spender: poolB.addr, AssetTypeID: "CNY", Amount: common.NewAmountFromInt(10)}
+	if res := stub.invoke("Org1MSP", nil, "approve", poolA.signReq(t, "approve", approve, &approve.SignStruct, nil)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	checkEvent("approve", common.TxEvent{TxType: common.TX_TYPE_APPROVE, AssetTypeID: "CNY", FromPool: poolA.addr, ToPool: poolB.addr, Amount: common.NewAmountFromInt(10)})
+
+	if res := stub.transfer(t, poolA, poolB, "1000", stub.transferTransient(t, poolA, []string{"a1"}, "b0", "a0")); res.Status == shim.OK {
+		t.Fatal("overspend accepted")
+	}
+	if len(stub.events) != 0 {
+		t.Errorf("rejected transfer emitted %d events", len(stub.events))
+	}
+	stub.events = nil
+
+	transient := stub.transferTransient(t, poolA, []string{"a1"}, "", "a2")
+	delete(transient, "newAssetAddr")
+	transient["newAssetAddrs"], _ = json.Marshal([]string{"b1", "c1"})
+	batch := &BatchTransferReq{FromPool: poolA.addr, AssetTypeID: "CNY", Payouts: []PayoutReq{
+		{ToPool: poolB.addr, Amount: common.NewAmountFromInt(30)},
+		{ToPool: poolC.addr, Amount: common.NewAmountFromInt(20)},
+	}}
+	if res := stub.invoke("Org1MSP", transient, "batchTransfer", poolA.signReq(t, "batchTransfer", batch, &batch.SignStruct, transient)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	checkEvent("batchTransfer",
+		common.TxEvent{TxType: common.TX_TYPE_TRANSFER, AssetTypeID: "CNY", FromPool: poolA.addr, ToPool: poolB.addr, Amount: common.NewAmountFromInt(30)},
+		common.TxEvent{TxType: common.TX_TYPE_TRANSFER, AssetTypeID: "CNY", FromPool: poolA.addr, ToPool: poolC.addr, Amount: common.NewAmountFromInt(20)})
+}
+
+func TestSpendRejected(t *testing.T) {
+	stub := newTestStub(t)
+	poolA := stub.addPool(t, "Org1MSP", "poolA")
+	poolB := stub.addPool(t, "Org2MSP", "poolB")
+
+	amount, _ := common.NewAmount("100")
+	issueTransient := map[string][]byte{"assetAddr": []byte("a1")}
+	issueReq := &TransferReq{ToPool: poolA.addr, Amount: amount, TxType: common.TX_TYPE_ISSUE, AssetTypeID: "CNY"}
+	if res := stub.invoke("Org1MSP", issueTransient, "issue", poolA.sign(t, issueReq, issueTransient)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := stub.transfer(t, poolA, poolB, "30", stub.transferTransient(t, poolA, []string{"a1"}, "b1", "a2")); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	//资产池A不能花费属于B的资产
+	if res := stub.transfer(t, poolA, poolB, "30", stub.transferTransient(t, poolA, []string{"b1"}, "b9", "")); res.Status == shim.OK {
+		t.Error("poolA spent poolB's asset")
+	}
+
+	//重放已执行的请求
+	value, _ := common.NewAmount("10")
+	transient := stub.transferTransient(t, poolA, []string{"a2"}, "b2", "a3")
+	req := &TransferReq{FromPool: poolA.addr, ToPool: poolB.addr, Amount: value, TxType: common.TX_TYPE_TRANSFER, AssetTypeID: "CNY"}
+	signed := poolA.sign(t, req, transient)
+	if res := stub.invoke("Org1MSP", transient, "transfer", signed); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := stub.invoke("Org1MSP", transient, "transfer", signed); res.Status == shim.OK {
+		t.Error("replayed request accepted")
+	}
+
+	//替换未签名的transient数据
+	transient = stub.transferTransient(t, poolA, []string{"a3"}, "b3", "a4")
+	signed = poolA.sign(t, &TransferReq{FromPool: poolA.addr, ToPool: poolB.addr, Amount: value, TxType: common.TX_TYPE_TRANSFER, AssetTypeID: "CNY"}, transient)
+	transient["newAssetAddr"] = []byte("attacker")
+	if res := stub.invoke("Org1MSP", transient, "transfer", signed); res.Status == shim.OK {
+		t.Error("substituted transient accepted")
+	}
+
+	//加密地址与输入不对应：用另一个未花费资产x1的加密地址和盲化因子花费a3
+	stub.issue(t, poolA, "CNY", "50", "x1")
+	x1 := stub.wallet(t, poolA)["x1"]
+	transient = stub.transferTransient(t, poolA, []string{"a3"}, "b4", "a5")
+	transient["encryptedAddrs"], _ = json.Marshal([]string{x1.EncryptedAddr})
+	transient["blindings"], _ = json.Marshal([]string{x1.Blinding})
+	signed = poolA.sign(t, &TransferReq{FromPool: poolA.addr, ToPool: poolB.addr, Amount: value, TxType: common.TX_TYPE_TRANSFER, AssetTypeID: "CNY"}, transient)
+	if res := stub.invoke("Org1MSP", transient, "transfer", signed); res.Status == shim.OK {
+		t.Error("spend with another output's encrypted addr accepted")
+	}
+	x1Key, _ := stub.CreateCompositeKey(common.OBJECT_TYPE_ASSET_ADDR, []string{poolA.addr, x1.EncryptedAddr})
+	var x1Record assetPool.AssetAddr
+	json.Unmarshal(stub.State[x1Key], &x1Record)
+	if x1Record.HasTransfered || x1Record.BurnTime != "" {
+		t.Errorf("x1 asset addr record touched: %+v", x1Record)
+	}
+	if err := stub.verifyOwnership(t, stub.queryAsset(t, "x1"), poolA); err != nil {
+		t.Errorf("x1: %v", err)
+	}
+
+	//找零地址与新资产地址相同
+	transient = stub.transferTransient(t, poolA, []string{"a3"}, "b4", "b4")
+	signed = poolA.sign(t, &TransferReq{FromPool: poolA.addr, ToPool: poolB.addr, Amount: value, TxType: common.TX_TYPE_TRANSFER, AssetTypeID: "CNY"}, transient)
+	if res := stub.invoke("Org1MSP", transient, "transfer", signed); res.Status == shim.OK {
+		t.Error("changeAddr equal to newAssetAddr accepted")
+	}
+	//赎回的找零地址与输入相同
+	transient = stub.transferTransient(t, poolA, []string{"a3"}, "", "a3")
+	delete(transient, "newAssetAddr")
+	redeemReq := &RedeemReq{FromPool: poolA.addr, AssetTypeID: "CNY", Amount: value, TxType: common.TX_TYPE_REDEEM}
+	if res := stub.invoke("Org1MSP", transient, "redeem", poolA.signReq(t, "redeem", redeemReq, &redeemReq.SignStruct, transient)); res.Status == shim.OK {
+		t.Error("redeem changeAddr equal to input accepted")
+	}
+
+	//重复输入
+	transient = stub.transferTransient(t, poolA, []string{"a3", "a3"}, "b5", "a6")
+	signed = poolA.sign(t, &TransferReq{FromPool: poolA.addr, ToPool: poolB.addr, Amount: value, TxType: common.TX_TYPE_TRANSFER, AssetTypeID: "CNY"}, transient)
+	if res := stub.invoke("Org1MSP", transient, "transfer", signed); res.Status == shim.OK {
+		t.Error("duplicate inputs accepted")
+	}
+}
+
+//对其他函数签名的请求不能提交给redeem
+func TestRedeemRejectsTransferRequest(t *testing.T) {
+	stub := newTestStub(t)
+	poolA := stub.addPool(t, "Org1MSP", "poolA")
+	poolB := stub.addPool(t, "Org2MSP", "poolB")
+	stub.issue(t, poolA, "CNY", "100", "a1")
+	circulating := func() string {
+		res := stub.invoke("Org1MSP", nil, "queryAssetType", "CNY")
+		if res.Status != shim.OK {
+			t.Fatal(res.Message)
+		}
+		var info asset.AssetInfo
+		json.Unmarshal(res.Payload, &info)
+		return info.Circulating.String()
+	}
+
+	value := common.NewAmountFromInt(30)
+	transient := stub.transferTransient(t, poolA, []string{"a1"}, "b1", "a2")
+	req := &TransferReq{FromPool: poolA.addr, ToPool: poolB.addr, Amount: value, TxType: common.TX_TYPE_TRANSFER, AssetTypeID: "CNY"}
+	if res := stub.invoke("Org1MSP", transient, "redeem", poolA.sign(t, req, transient)); res.Status == shim.OK {
+		t.Error("request signed for transfer accepted by redeem")
+	}
+	req = &TransferReq{FromPool: poolA.addr, ToPool: poolB.addr, Amount: value, TxType: common.TX_TYPE_TRANSFER, AssetTypeID: "CNY"}
+	if res := stub.invoke("Org1MSP", transient, "redeem", poolA.signReq(t, "redeem", req, &req.SignStruct, transient)); res.Status == shim.OK {
+		t.Error("transfer txType accepted by redeem")
+	}
+	if got := circulating(); got != "100" {
+		t.Fatalf("circulating = %s after rejected redeems, want 100", got)
+	}
+
+	transient = stub.transferTransient(t, poolA, []string{"a1"}, "", "a2")
+	delete(transient, "newAssetAddr")
+	redeemReq := &RedeemReq{FromPool: poolA.addr, AssetTypeID: "CNY", Amount: value, TxType: common.TX_TYPE_REDEEM}
+	if res := stub.invoke("Org1MSP", transient, "redeem", poolA.signReq(t, "redeem", redeemReq, &redeemReq.SignStruct, transient)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if got := circulating(); got != "70" {
+		t.Errorf("circulating = %s after redeem, want 70", got)
+	}
+}
+
+func TestMultiTransfer(t *testing.T) {
+	stub := newTestStub(t)
+	poolA := stub.addPool(t, "Org1MSP", "poolA")
+	poolB := stub.addPool(t, "Org2MSP", "poolB")
+	stub.issue(t, poolA, "CNY", "100", "a1")
+	stub.issue(t, poolB, "BOND", "5", "b1")
+
+	//券款对付：A付款给B，B交券给A
+	legs := []TransferLeg{
+		{FromPool: poolA.addr, ToPool: poolB.addr, AssetTypeID: "CNY", Amount: common.NewAmountFromInt(60)},
+		{FromPool: poolB.addr, ToPool: poolA.addr, AssetTypeID: "BOND", Amount: common.NewAmountFromInt(2)},
+	}
+	transient := map[string][]byte{}
+	for i, v := range []map[string][]byte{
+		stub.transferTransient(t, poolA, []string{"a1"}, "b2", "a2"),
+		stub.transferTransient(t, poolB, []string{"b1"}, "a3", "b3"),
+	} {
+		for k, val := range v {
+			transient[legTransientPrefix(i)+k] = val
+		}
+	}
+	addEncryptSeed(t, transient)
+	hashes, _ := client.HashTransient(transient)
+	poolA.nonce++
+	poolB.nonce++
+	req := MultiTransferReq{Legs: legs, MultiSignStruct: MultiSignStruct{
+		Function:        "multiTransfer",
+		Nonces:          map[string]uint64{poolA.addr: poolA.nonce, poolB.addr: poolB.nonce},
+		ExpireTime:      testExpireTime,
+		TransientHashes: hashes,
+	}}
+	signA, err := client.SignMultiPartyRequest(poolA.key, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signB, _ := client.SignMultiPartyRequest(poolB.key, req)
+
+	//缺少B的签名
+	req.Signs = map[string]string{poolA.addr: signA}
+	bytes, _ := json.Marshal(req)
+	if res := stub.invoke("Org1MSP", transient, "multiTransfer", string(bytes)); res.Status == shim.OK {
+		t.Fatal("multiTransfer without poolB's sign accepted")
+	}
+
+	req.Signs[poolB.addr] = signB
+	bytes, _ = json.Marshal(req)
+	res := stub.invoke("Org1MSP", transient, "multiTransfer", string(bytes))
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	for addr, pool := range map[string]*testPool{"b2": poolB, "a2": poolA, "a3": poolA, "b3": poolB} {
+		if err := stub.verifyOwnership(t, stub.queryAsset(t, addr), pool); err != nil {
+			t.Errorf("%s: %v", addr, err)
+		}
+	}
+	if a3 := stub.queryAsset(t, "a3"); a3.AssetTypeID != "BOND" || a3.Value.String() != "2" {
+		t.Errorf("a3 = %+v", a3)
+	}
+
+	//重放
+	if res := stub.invoke("Org1MSP", transient, "multiTransfer", string(bytes)); res.Status == shim.OK {
+		t.Error("replayed multiTransfer accepted")
+	}
+}
+
+//各段transient加上leg<i>.前缀，由signers中的转出资产池签名后提交
+func (s *testStub) multiTransfer(t *testing.T, legs []TransferLeg, legTransients []map[string][]byte, signers ...*testPool) pb.Response {
+	transient := map[string][]byte{}
+	for i, v := range legTransients {
+		for k, val := range v {
+			transient[legTransientPrefix(i)+k] = val
+		}
+	}
+	addEncryptSeed(t, transient)
+	hashes, _ := client.HashTransient(transient)
+	req := MultiTransferReq{Legs: legs, MultiSignStruct: MultiSignStruct{
+		Function:        "multiTransfer",
+		Nonces:          map[string]uint64{},
+		ExpireTime:      testExpireTime,
+		TransientHashes: hashes,
+	}}
+	for _, v := range signers {
+		v.nonce++
+		req.Nonces[v.addr] = v.nonce
+	}
+	req.Signs = map[string]string{}
+	for _, v := range signers {
+		sign, err := client.SignMultiPartyRequest(v.key, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Signs[v.addr] = sign
+	}
+	bytes, _ := json.Marshal(req)
+	return s.invoke(signers[0].mspID, transient, "multiTransfer", string(bytes))
+}
+
+//各段的输入、新资产地址与找零地址在整笔交易内不能重复
+func TestMultiTransferAddrCollision(t *testing.T) {
+	stub := newTestStub(t)
+	poolA := stub.addPool(t, "Org1MSP", "poolA")
+	poolB := stub.addPool(t, "Org2MSP", "poolB")
+	stub.issue(t, poolA, "CNY", "100", "a1")
+	stub.issue(t, poolB, "BOND", "5", "b1")
+	legs := []TransferLeg{
+		{FromPool: poolA.addr, ToPool: poolB.addr, AssetTypeID: "CNY", Amount: common.NewAmountFromInt(60)},
+		{FromPool: poolB.addr, ToPool: poolA.addr, AssetTypeID: "BOND", Amount: common.NewAmountFromInt(2)},
+	}
+
+	//b2在本交易中才生成，客户端无从得到其加密地址，此处以占位值构造输入
+	spendB2 := stub.transferTransient(t, poolB, []string{"b1", "b2"}, "a3", "b3")
+	spendB2["encryptedAddrs"], _ = json.Marshal([]string{stub.wallet(t, poolB)["b1"].EncryptedAddr, "b2-encrypted"})
+	cases := []struct {
+		name string
+		legs []map[string][]byte
+		want string
+	}{
+		{"new asset addr equals change of another leg", []map[string][]byte{
+			stub.transferTransient(t, poolA, []string{"a1"}, "b2", "a2"),
+			stub.transferTransient(t, poolB, []string{"b1"}, "a2", "b3"),
+		}, "asset addr a2 is used twice"},
+		{"change equals input of another leg", []map[string][]byte{
+			stub.transferTransient(t, poolA, []string{"a1"}, "b2", "a2"),
+			stub.transferTransient(t, poolB, []string{"b1"}, "a3", "a1"),
+		}, "asset addr a1 is the same as an input"},
+		{"input equals output of another leg", []map[string][]byte{
+			stub.transferTransient(t, poolA, []string{"a1"}, "b2", "a2"),
+			spendB2,
+		}, "input b2 is an output of the same tx"},
+	}
+	for _, c := range cases {
+		res := stub.multiTransfer(t, legs, c.legs, poolA, poolB)
+		if res.Status == shim.OK {
+			t.Fatalf("%s: multiTransfer accepted", c.name)
+		}
+		if !strings.Contains(res.Message, c.want) {
+			t.Errorf("%s: message = %q, want %q", c.name, res.Message, c.want)
+		}
+	}
+	stub.checkAsset(t, "a1", poolA, "100")
+}
+
+func TestBatchTransfer(t *testing.T) {
+	stub := newTestStub(t)
+	poolA := stub.addPool(t, "Org1MSP", "poolA")
+	poolB := stub.addPool(t, "Org2MSP", "poolB")
+	poolC := stub.addPool(t, "Org2MSP", "poolC")
+	stub.issue(t, poolA, "CNY", "50", "a1")
+	stub.issue(t, poolA, "CNY", "40", "a2")
+
+	batch := func(newAssetAddrs []string) pb.Response {
+		transient := stub.transferTransient(t, poolA, []string{"a1", "a2"}, "", "a3")
+		delete(transient, "newAssetAddr")
+		transient["newAssetAddrs"], _ = json.Marshal(newAssetAddrs)
+		req := &BatchTransferReq{FromPool: poolA.addr, AssetTypeID: "CNY", Payouts: []PayoutReq{
+			{ToPool: poolB.addr, Amount: common.NewAmountFromInt(30)},
+			{ToPool: poolC.addr, Amount: common.NewAmountFromInt(20)},
+			{ToPool: poolB.addr, Amount: common.NewAmountFromInt(10)},
+		}}
+		return stub.invoke("Org1MSP", transient, "batchTransfer", poolA.signReq(t, "batchTransfer", req, &req.SignStruct, transient))
+	}
+
+	if res := batch([]string{"b1", "c1", "b1"}); res.Status == shim.OK {
+		t.Fatal("duplicate newAssetAddrs accepted")
+	}
+	if res := batch([]string{"b1", "c1"}); res.Status == shim.OK {
+		t.Fatal("misaligned newAssetAddrs accepted")
+	}
+
+	res := batch([]string{"b1", "c1", "b2"})
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	var selection assetPool.Selection
+	json.Unmarshal(res.Payload, &selection)
+	if len(selection.Inputs) != 2 || selection.Change.String() != "30" {
+		t.Errorf("selection = %+v", selection)
+	}
+	for addr, want := range map[string]struct {
+		pool  *testPool
+		value string
+	}{"b1": {poolB, "30"}, "c1": {poolC, "20"}, "b2": {poolB, "10"}, "a3": {poolA, "30"}} {
+		a := stub.queryAsset(t, addr)
+		if err := stub.verifyOwnership(t, a, want.pool); err != nil {
+			t.Errorf("%s: %v", addr, err)
+		}
+		if a.Value.String() != want.value {
+			t.Errorf("%s value = %s, want %s", addr, a.Value, want.value)
+		}
+	}
+}
+
+func TestHashTimeLock(t *testing.T) {
+	stub := newTestStub(t)
+	poolA := stub.addPool(t, "Org1MSP", "poolA")
+	poolB := stub.addPool(t, "Org2MSP", "poolB")
+	stub.issue(t, poolA, "CNY", "100", "a1")
+
+	preimage := hex.EncodeToString([]byte("swap secret"))
+	hash := sha256.Sum256([]byte("swap secret"))
+	lockTime := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	stub.txTime = lockTime.Unix()
+	clock := lockTime
+	common.EndorserNow = func() time.Time { return clock }
+	defer func() { common.EndorserNow = time.Now }()
+
+	signAndInvoke := func(pool *testPool, fn string, req interface{}, signStruct *SignStruct, transient map[string][]byte) pb.Response {
+		return stub.invoke(pool.mspID, transient, fn, pool.signReq(t, fn, req, signStruct, transient))
+	}
+	tryLock := func(input string, newAssetAddr string, changeAddr string) pb.Response {
+		transient := stub.transferTransient(t, poolA, []string{input}, newAssetAddr, changeAddr)
+		req := &LockHTLCReq{Sender: poolA.addr, Recipient: poolB.addr, AssetTypeID: "CNY", Amount: common.NewAmountFromInt(40),
+			HashLock: hex.EncodeToString(hash[:]), Timeout: lockTime.Add(time.Hour).Format(time.RFC3339)}
+		return signAndInvoke(poolA, "lockHTLC", req, &req.SignStruct, transient)
+	}
+	lock := func(input string, newAssetAddr string, changeAddr string) string {
+		res := tryLock(input, newAssetAddr, changeAddr)
+		if res.Status != shim.OK {
+			t.Fatal(res.Message)
+		}
+		var l assetPool.HashTimeLock
+		json.Unmarshal(res.Payload, &l)
+		return l.LockID
+	}
+	claim := func(lockID string, preimage string, newAssetAddr string) pb.Response {
+		transient := map[string][]byte{"newAssetAddr": []byte(newAssetAddr)}
+		req := &ClaimHTLCReq{Recipient: poolB.addr, LockID: lockID, Preimage: preimage}
+		return signAndInvoke(poolB, "claimHTLC", req, &req.SignStruct, transient)
+	}
+	refund := func(lockID string, newAssetAddr string) pb.Response {
+		transient := map[string][]byte{"newAssetAddr": []byte(newAssetAddr)}
+		req := &RefundHTLCReq{Sender: poolA.addr, LockID: lockID}
+		return signAndInvoke(poolA, "refundHTLC", req, &req.SignStruct, transient)
+	}
+
+	//托管地址与找零地址相同
+	if res := tryLock("a1", "h1", "h1"); res.Status == shim.OK {
+		t.Fatal("escrow addr equal to changeAddr accepted")
+	}
+	//交易时间戳超出背书节点时钟允许偏差
+	stub.txTime = lockTime.Add(10 * time.Minute).Unix()
+	if res := tryLock("a1", "h1", "a2"); res.Status == shim.OK {
+		t.Fatal("lock with skewed timestamp accepted")
+	}
+	stub.txTime = lockTime.Unix()
+	claimed := lock("a1", "h1", "a2")
+	//锁定记录须经接收方机构背书才能改写
+	key, _ := stub.CreateCompositeKey(common.OBJECT_TYPE_HTLC, []string{claimed})
+	policy, _ := stub.GetStateValidationParameter(key)
+	if ep, err := statebased.NewStateEP(policy); err != nil || !reflect.DeepEqual(ep.ListOrgs(), []string{"Org2MSP"}) {
+		t.Errorf("endorsement policy of %s = %s", claimed, policy)
+	}
+	if res := refund(claimed, "a9"); res.Status == shim.OK {
+		t.Fatal("refund before timeout accepted")
+	}
+	if res := claim(claimed, hex.EncodeToString([]byte("wrong")), "b1"); res.Status == shim.OK {
+		t.Fatal("wrong preimage accepted")
+	}
+	if res := claim(claimed, preimage, "b1"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := claim(claimed, preimage, "b2"); res.Status == shim.OK {
+		t.Fatal("double claim accepted")
+	}
+	res := stub.invoke("Org1MSP", nil, "queryHTLC", claimed)
+	var l assetPool.HashTimeLock
+	json.Unmarshal(res.Payload, &l)
+	if l.Status != common.HTLC_STATUS_CLAIMED || l.Preimage != preimage {
+		t.Errorf("lock = %+v", l)
+	}
+	if a := stub.queryAsset(t, "b1"); stub.verifyOwnership(t, a, poolB) != nil || a.Value.String() != "40" {
+		t.Errorf("claimed asset = %+v", a)
+	}
+
+	refunded := lock("a2", "h2", "a3")
+
+	//发送方以偏后的时间戳提前退回
+	stub.txTime = lockTime.Add(2 * time.Hour).Unix()
+	if res := refund(refunded, "a4"); res.Status == shim.OK {
+		t.Fatal("refund with forged timestamp accepted")
+	}
+	clock = lockTime.Add(2 * time.Hour)
+	if res := claim(refunded, preimage, "b3"); res.Status == shim.OK {
+		t.Fatal("claim after timeout accepted")
+	}
+	if res := refund(refunded, "a4"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if a := stub.queryAsset(t, "a4"); stub.verifyOwnership(t, a, poolA) != nil || a.Value.String() != "40" {
+		t.Errorf("refunded asset = %+v", a)
+	}
+}
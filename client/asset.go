@@ -0,0 +1,45 @@
+package client
+
+import (
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	ast "github.com/FabricTransaction/asset"
+	"github.com/FabricTransaction/assetPool"
+)
+
+//校验queryAsset取回的资产属于指定资产池且未被消耗，接收方可据此确认转入的资产今后可由本资产池花费
+//encryptedAddr为该资产登记的加密资产地址，blinding为解密得到的盲化因子
+func VerifyAssetOwnership(asset ast.Asset, poolID string, publicKey string, encryptedAddr string, blinding string) error {
+	if asset.HasTransfered {
+		return errors.New("asset " + asset.AssetAddr + " has been spent")
+	}
+	if asset.Sign != ast.OwnershipCommitment(poolID, publicKey, asset.AssetTypeID, asset.AssetAddr, asset.Value, encryptedAddr, blinding) {
+		return errors.New("asset " + asset.AssetAddr + " is not owned by asset pool " + poolID)
+	}
+	return nil
+}
+
+//解密listAssetAddrs下载的加密资产地址，返回明文地址到花费输入的映射
+//花费时将对应的花费输入放入transient的inputs
+func DecryptAssetAddrs(privateKey crypto.PrivateKey, addrs []assetPool.AssetAddr) (map[string]assetPool.SpendInput, error) {
+	plain := make(map[string]assetPool.SpendInput, len(addrs))
+	for _, v := range addrs {
+		data, err := base64.StdEncoding.DecodeString(v.EncryptAssetAddr)
+		if err != nil {
+			return nil, err
+		}
+		secretBytes, err := decryptByPrivateKey(privateKey, data)
+		if err != nil {
+			return nil, errors.New("decrypt " + v.EncryptAssetAddr + " failed: " + err.Error())
+		}
+		var secret assetPool.AssetAddrSecret
+		if err := json.Unmarshal(secretBytes, &secret); err != nil {
+			return nil, errors.New("unmarshal " + v.EncryptAssetAddr + " failed: " + err.Error())
+		}
+		plain[secret.AssetAddr] = assetPool.SpendInput{AssetAddr: secret.AssetAddr, EncryptedAddr: v.EncryptAssetAddr, Blinding: secret.Blinding}
+	}
+	return plain, nil
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/FabricTransaction/common"
+	"github.com/FabricTransaction/common/securityTool"
+)
+
+//对请求签名，返回链码可直接验签的请求JSON
+//签名内容为去掉sign字段后的规范化JSON，RSA使用PKCS#1 v1.5，ECDSA使用low-S的ASN.1 DER，均对SHA-256摘要签名
+func SignRequest(privateKey crypto.PrivateKey, req interface{}) (string, error) {
+	bytes, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	canonical, err := securityTool.CanonicalizeJSON(bytes, securityTool.SIGN_FIELD)
+	if err != nil {
+		return "", err
+	}
+	sign, err := signByPrivateKey(privateKey, canonical)
+	if err != nil {
+		return "", err
+	}
+	signed, err := securityTool.AppendSignToJSON(canonical, sign)
+	if err != nil {
+		return "", err
+	}
+	return string(signed), nil
+}
+
+//多方签名请求中本资产池的签名，签名内容为去掉signs字段后的规范化JSON
+//各方对同一请求分别签名后，以资产池ID为键放入signs字段
+func SignMultiPartyRequest(privateKey crypto.PrivateKey, req interface{}) (string, error) {
+	bytes, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	canonical, err := securityTool.CanonicalizeJSON(bytes, securityTool.MULTI_SIGN_FIELD)
+	if err != nil {
+		return "", err
+	}
+	return signByPrivateKey(privateKey, canonical)
+}
+
+//计算transient各字段的摘要，填入请求的transientHashes字段后再签名
+func HashTransient(transient map[string][]byte) (map[string]string, error) {
+	hashes := make(map[string]string, len(transient))
+	for k, v := range transient {
+		hash, err := securityTool.CalcSHA256Base64Str(string(v))
+		if err != nil {
+			return nil, err
+		}
+		hashes[k] = hash
+	}
+	return hashes, nil
+}
+
+//生成transient中的加密随机种子encryptSeed，链码由其派生加密资产地址等所需的随机数
+//每个请求使用新的种子，种子须与其他transient字段一样计入transientHashes
+func NewEncryptSeed() ([]byte, error) {
+	seed := make([]byte, common.MIN_ENCRYPT_SEED_LEN)
+	if _, err := io.ReadFull(rand.Reader, seed); err != nil {
+		return nil, err
+	}
+	return seed, nil
+}
+
+func signByPrivateKey(privateKey crypto.PrivateKey, data []byte) (string, error) {
+	hashByte := sha256.Sum256(data)
+	var sign []byte
+	var err error
+	switch key := privateKey.(type) {
+	case *rsa.PrivateKey:
+		sign, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashByte[:])
+	case *ecdsa.PrivateKey:
+		var r, s *big.Int
+		if r, s, err = ecdsa.Sign(rand.Reader, key, hashByte[:]); err == nil {
+			sign, err = securityTool.EncodeECDSASignature(key.Curve, r, s)
+		}
+	default:
+		return "", errors.New("unsupported private key type")
+	}
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sign), nil
+}
@@ -0,0 +1,54 @@
+package client
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"testing"
+
+	"github.com/FabricTransaction/common/securityTool"
+)
+
+type testReq struct {
+	FromPool string `json:"fromPool"`
+	Amount   string `json:"amount"`
+	Memo     string `json:"memo"`
+	Sign     string `json:"sign"`
+}
+
+func TestSignRequest(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		priv crypto.PrivateKey
+		pub  interface{}
+		tool securityTool.SecurityTool
+	}{
+		{rsaKey, &rsaKey.PublicKey, securityTool.RSATool{}},
+		{ecKey, &ecKey.PublicKey, securityTool.ECTool{}},
+	}
+	for _, c := range cases {
+		pubBytes, _ := x509.MarshalPKIXPublicKey(c.pub)
+		publicKey := base64.StdEncoding.EncodeToString(pubBytes)
+
+		signed, err := SignRequest(c.priv, testReq{FromPool: "poolA", Amount: "1.5", Memo: `"<quoted>"`})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ok, err := securityTool.CheckJSONObjectSignatureString(c.tool, signed, publicKey)
+		if !ok || err != nil {
+			t.Errorf("%T: verify %s failed: %v", c.tool, signed, err)
+		}
+	}
+}
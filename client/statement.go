@@ -0,0 +1,69 @@
+package client
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/FabricTransaction/common/securityTool"
+	"github.com/FabricTransaction/txLog"
+)
+
+//解析PEM格式的资产池私钥，支持PKCS#1、SEC 1(EC)与PKCS#8
+func ParsePrivateKey(privateKeyPEM string) (crypto.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(privateKeyPEM))
+	if block == nil {
+		return nil, errors.New("私钥解析出错")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return x509.ParsePKCS8PrivateKey(block.Bytes)
+}
+
+//使用资产池私钥解密queryAccountLog下载的对账单，结果按交易时间排序
+func DecryptStatement(privateKey crypto.PrivateKey, logs []txLog.AccountLog) ([]txLog.AccountLogDetail, error) {
+	details := make([]txLog.AccountLogDetail, 0, len(logs))
+	for _, v := range logs {
+		bytes, err := securityTool.OpenSealedData(v.Detail, func(encryptedKey []byte) ([]byte, error) {
+			return decryptByPrivateKey(privateKey, encryptedKey)
+		})
+		if err != nil {
+			return nil, errors.New("decrypt " + v.LogID + " failed: " + err.Error())
+		}
+		var detail txLog.AccountLogDetail
+		if err := json.Unmarshal(bytes, &detail); err != nil {
+			return nil, err
+		}
+		details = append(details, detail)
+	}
+
+	//TxTime为RFC3339Nano格式，小数部分末尾的0被省略，不能按字符串排序；无法解析的排在最前
+	sort.SliceStable(details, func(i, j int) bool {
+		ti, _ := time.Parse(time.RFC3339Nano, details[i].TxTime)
+		tj, _ := time.Parse(time.RFC3339Nano, details[j].TxTime)
+		return ti.Before(tj)
+	})
+	return details, nil
+}
+
+func decryptByPrivateKey(privateKey crypto.PrivateKey, data []byte) ([]byte, error) {
+	switch key := privateKey.(type) {
+	case *rsa.PrivateKey:
+		return rsa.DecryptPKCS1v15(rand.Reader, key, data)
+	case *ecdsa.PrivateKey:
+		return securityTool.DecryptByECPrivateKey(key, data)
+	default:
+		return nil, errors.New("unsupported private key type")
+	}
+}
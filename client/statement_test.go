@@ -0,0 +1,100 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"testing"
+
+	"github.com/FabricTransaction/common"
+	"github.com/FabricTransaction/common/securityTool"
+	"github.com/FabricTransaction/txLog"
+)
+
+func TestDecryptStatement(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubBytes, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey := base64.StdEncoding.EncodeToString(pubBytes)
+	privateKeyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+
+	amount, _ := common.NewAmount("12.5")
+	want := []txLog.AccountLogDetail{
+		{TxID: "tx1", TxType: common.TX_TYPE_TRANSFER_IN, Counterparty: "poolB", AssetTypeID: "CNY", Amount: amount, TxTime: "2018-01-01T00:00:00.1Z"},
+		//RFC3339Nano省略小数末尾的0，按字符串比较会排在tx1之前
+		{TxID: "tx2", TxType: common.TX_TYPE_TRANSFER_OUT, Counterparty: "poolC", AssetTypeID: "CNY", Amount: amount, TxTime: "2018-01-01T00:00:00.12Z"},
+	}
+
+	var logs []txLog.AccountLog
+	for i := len(want) - 1; i >= 0; i-- {
+		bytes, _ := json.Marshal(want[i])
+		sealed, err := securityTool.SealByPoolPublicKey(securityTool.RSATool{}, rand.Reader, publicKey, bytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		logs = append(logs, txLog.AccountLog{LogID: want[i].TxID, AssetPoolAddr: "poolA", Detail: sealed})
+	}
+
+	priv, err := ParsePrivateKey(privateKeyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := DecryptStatement(priv, logs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d details, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].TxID != want[i].TxID || got[i].Counterparty != want[i].Counterparty || got[i].Amount.Cmp(want[i].Amount) != 0 {
+			t.Errorf("detail %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	if _, err := DecryptStatement(other, logs); err == nil {
+		t.Error("decrypt with another pool's key should fail")
+	}
+}
+
+func TestDecryptStatementEC(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubBytes, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	publicKey := base64.StdEncoding.EncodeToString(pubBytes)
+	ecBytes, _ := x509.MarshalECPrivateKey(key)
+	privateKeyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: ecBytes}))
+
+	amount, _ := common.NewAmount("3")
+	want := txLog.AccountLogDetail{TxID: "tx1", TxType: common.TX_TYPE_TRANSFER_IN, Counterparty: "poolB", AssetTypeID: "CNY", Amount: amount}
+	bytes, _ := json.Marshal(want)
+	sealed, err := securityTool.SealByPoolPublicKey(securityTool.ECTool{}, rand.Reader, publicKey, bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	priv, err := ParsePrivateKey(privateKeyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := DecryptStatement(priv, []txLog.AccountLog{{LogID: "log1", AssetPoolAddr: "poolA", Detail: sealed}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].TxID != want.TxID || got[0].Amount.Cmp(amount) != 0 {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
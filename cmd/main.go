@@ -0,0 +1,14 @@
+package main
+
+import (
+	"log"
+
+	"github.com/FabricTransaction"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func main() {
+	if err := shim.Start(new(FabricTransaction.FabricTransactionChaincode)); err != nil {
+		log.Printf("start FabricTransactionChaincode failed: %s", err)
+	}
+}
@@ -0,0 +1,197 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+const (
+	MAX_DECIMALS = 18
+	MAX_EXPONENT = 64
+)
+
+//定点小数，数值为value * 10^(-scale)，运算均为精确运算，避免float64在各节点间的舍入误差
+//JSON中以规范形式的十进制字符串表示，如"12.34"
+type Amount struct {
+	value *big.Int
+	scale int
+}
+
+var bigTen = big.NewInt(10)
+
+func NewAmountFromInt(i int64) Amount {
+	return Amount{value: big.NewInt(i)}
+}
+
+func NewAmount(str string) (Amount, error) {
+	str = strings.TrimSpace(str)
+	exp := 0
+	if i := strings.IndexAny(str, "eE"); i >= 0 {
+		e, err := strconv.Atoi(str[i+1:])
+		if err != nil || e > MAX_EXPONENT || e < -MAX_EXPONENT {
+			return Amount{}, fmt.Errorf("invalid amount %q", str)
+		}
+		exp = e
+		str = str[:i]
+	}
+
+	intPart, fracPart := str, ""
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		intPart, fracPart = str[:i], str[i+1:]
+	}
+	sign := ""
+	if strings.HasPrefix(intPart, "-") || strings.HasPrefix(intPart, "+") {
+		sign, intPart = intPart[:1], intPart[1:]
+	}
+	if intPart == "" && fracPart == "" || !isDigits(intPart) || !isDigits(fracPart) {
+		return Amount{}, fmt.Errorf("invalid amount %q", str)
+	}
+
+	value, ok := new(big.Int).SetString(sign+intPart+fracPart, 10)
+	if !ok {
+		return Amount{}, fmt.Errorf("invalid amount %q", str)
+	}
+	return Amount{value: value, scale: len(fracPart) - exp}.normalize(), nil
+}
+
+func isDigits(str string) bool {
+	for _, c := range str {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (a Amount) bigValue() *big.Int {
+	if a.value == nil {
+		return new(big.Int)
+	}
+	return a.value
+}
+
+//去掉小数部分末尾的0，scale为负时展开为整数
+func (a Amount) normalize() Amount {
+	value := new(big.Int).Set(a.bigValue())
+	scale := a.scale
+	if scale < 0 {
+		value.Mul(value, new(big.Int).Exp(bigTen, big.NewInt(int64(-scale)), nil))
+		scale = 0
+	}
+	if value.Sign() == 0 {
+		return Amount{value: value}
+	}
+	mod := new(big.Int)
+	for scale > 0 {
+		q, r := new(big.Int).QuoRem(value, bigTen, mod)
+		if r.Sign() != 0 {
+			break
+		}
+		value = q
+		scale--
+	}
+	return Amount{value: value, scale: scale}
+}
+
+//将a、b放大到相同的scale
+func align(a, b Amount) (*big.Int, *big.Int, int) {
+	x, y := new(big.Int).Set(a.bigValue()), new(big.Int).Set(b.bigValue())
+	scale := a.scale
+	if b.scale > scale {
+		x.Mul(x, new(big.Int).Exp(bigTen, big.NewInt(int64(b.scale-a.scale)), nil))
+		scale = b.scale
+	} else if a.scale > b.scale {
+		y.Mul(y, new(big.Int).Exp(bigTen, big.NewInt(int64(a.scale-b.scale)), nil))
+	}
+	return x, y, scale
+}
+
+func (a Amount) Add(b Amount) Amount {
+	x, y, scale := align(a, b)
+	return Amount{value: x.Add(x, y), scale: scale}.normalize()
+}
+
+func (a Amount) Sub(b Amount) Amount {
+	x, y, scale := align(a, b)
+	return Amount{value: x.Sub(x, y), scale: scale}.normalize()
+}
+
+func (a Amount) Neg() Amount {
+	return Amount{value: new(big.Int).Neg(a.bigValue()), scale: a.scale}
+}
+
+func (a Amount) Cmp(b Amount) int {
+	x, y, _ := align(a, b)
+	return x.Cmp(y)
+}
+
+func (a Amount) Sign() int {
+	return a.bigValue().Sign()
+}
+
+func (a Amount) IsZero() bool {
+	return a.Sign() == 0
+}
+
+//小数位数
+func (a Amount) Decimals() int {
+	return a.normalize().scale
+}
+
+//校验小数位数不超过资产类型支持的位数
+func (a Amount) CheckDecimals(decimals uint8) error {
+	if a.Decimals() > int(decimals) {
+		return fmt.Errorf("amount %s exceeds %d decimals", a, decimals)
+	}
+	return nil
+}
+
+func (a Amount) String() string {
+	n := a.normalize()
+	digits := new(big.Int).Abs(n.value).String()
+	sign := ""
+	if n.value.Sign() < 0 {
+		sign = "-"
+	}
+	if n.scale == 0 {
+		return sign + digits
+	}
+	if len(digits) <= n.scale {
+		digits = strings.Repeat("0", n.scale-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-n.scale] + "." + digits[len(digits)-n.scale:]
+}
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(a.String())), nil
+}
+
+//只接受String()输出的规范形式，同一数额在签名请求中只有一种写法，如"10.5"，不接受10.5、"10.50"或"1.05e1"
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	str := string(data)
+	if str == "null" {
+		return nil
+	}
+	if !strings.HasPrefix(str, "\"") {
+		return fmt.Errorf("amount %s must be a JSON string", str)
+	}
+	unquoted, err := strconv.Unquote(str)
+	if err != nil {
+		return err
+	}
+	if unquoted == "" {
+		return errors.New("empty amount")
+	}
+	amount, err := NewAmount(unquoted)
+	if err != nil {
+		return err
+	}
+	if amount.String() != unquoted {
+		return fmt.Errorf("amount %q is not canonical, want %q", unquoted, amount.String())
+	}
+	*a = amount
+	return nil
+}
@@ -0,0 +1,127 @@
+package common
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func mustAmount(t *testing.T, str string) Amount {
+	a, err := NewAmount(str)
+	if err != nil {
+		t.Fatalf("NewAmount(%q) failed: %s", str, err)
+	}
+	return a
+}
+
+func TestNewAmount(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"0", "0"},
+		{"10", "10"},
+		{"0.10", "0.1"},
+		{".5", "0.5"},
+		{"-1.250", "-1.25"},
+		{"0.000001", "0.000001"},
+		{"1.5e2", "150"},
+		{"15E-3", "0.015"},
+		{"123456789012345678901234567890.123456789", "123456789012345678901234567890.123456789"},
+	}
+	for _, tt := range tests {
+		if got := mustAmount(t, tt.in).String(); got != tt.want {
+			t.Errorf("NewAmount(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", ".", "-", "1.2.3", "abc", "1e", "0x10", "1e1000"} {
+		if _, err := NewAmount(in); err == nil {
+			t.Errorf("NewAmount(%q) should fail", in)
+		}
+	}
+}
+
+func TestAmountArithmetic(t *testing.T) {
+	a := mustAmount(t, "0.1")
+	b := mustAmount(t, "0.2")
+	if got := a.Add(b).String(); got != "0.3" {
+		t.Errorf("0.1 + 0.2 = %s, want 0.3", got)
+	}
+	if got := a.Sub(b).String(); got != "-0.1" {
+		t.Errorf("0.1 - 0.2 = %s, want -0.1", got)
+	}
+	if a.Add(b).Cmp(mustAmount(t, "0.30")) != 0 {
+		t.Error("0.1 + 0.2 should equal 0.30")
+	}
+	if a.Cmp(b) >= 0 || b.Cmp(a) <= 0 {
+		t.Error("0.1 should be less than 0.2")
+	}
+	if a.String() != "0.1" || b.String() != "0.2" {
+		t.Error("operands must not be modified")
+	}
+
+	var zero Amount
+	if !zero.IsZero() || zero.String() != "0" || zero.Add(a).Cmp(a) != 0 {
+		t.Error("zero value Amount should behave as 0")
+	}
+}
+
+func TestAmountCheckDecimals(t *testing.T) {
+	if err := mustAmount(t, "1.2300").CheckDecimals(2); err != nil {
+		t.Errorf("1.2300 should fit 2 decimals: %s", err)
+	}
+	if err := mustAmount(t, "1.234").CheckDecimals(2); err == nil {
+		t.Error("1.234 should not fit 2 decimals")
+	}
+	if err := mustAmount(t, "100").CheckDecimals(0); err != nil {
+		t.Errorf("100 should fit 0 decimals: %s", err)
+	}
+}
+
+//金额JSON的标准向量，同一数额只接受一种写法
+var amountJSONVectors = []struct {
+	input string
+	want  string //为空表示应拒绝
+}{
+	{`"10.5"`, "10.5"},
+	{`"0"`, "0"},
+	{`"-1.25"`, "-1.25"},
+	{`"123456789012345678901234567890.123456789"`, "123456789012345678901234567890.123456789"},
+	{`10.5`, ""},
+	{`10.50`, ""},
+	{`1.05e1`, ""},
+	{`"10.50"`, ""},
+	{`"1.05e1"`, ""},
+	{`"010.5"`, ""},
+	{`"+10.5"`, ""},
+	{`"-0"`, ""},
+	{`".5"`, ""},
+	{`""`, ""},
+}
+
+func TestAmountJSON(t *testing.T) {
+	for _, v := range amountJSONVectors {
+		var a Amount
+		err := json.Unmarshal([]byte(v.input), &a)
+		if v.want == "" {
+			if err == nil {
+				t.Errorf("unmarshal %s should fail, got %s", v.input, a)
+			}
+			continue
+		}
+		if err != nil || a.String() != v.want {
+			t.Errorf("unmarshal %s = %s, %v, want %s", v.input, a, err, v.want)
+		}
+	}
+
+	v := struct {
+		A Amount `json:"a"`
+	}{mustAmount(t, "12.50")}
+	bytes, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(bytes) != `{"a":"12.5"}` {
+		t.Errorf("marshal got %s", bytes)
+	}
+}
@@ -19,6 +19,36 @@ const (
 	OBJECT_TYPE_ORG        = "organization"
 	OBJECT_TYPE_ASSET_INFO = "assetInfo"
 	OBJECT_TYPE_ASSET_ADDR = "AssetAddr"
+	OBJECT_TYPE_ALLOWANCE  = "allowance"
+	OBJECT_TYPE_LISTING    = "listing"
+	OBJECT_TYPE_REDEMPTION = "redemption"
+	OBJECT_TYPE_POOL_NONCE = "poolNonce"
+	OBJECT_TYPE_HTLC       = "htlc"
+)
+
+const (
+	ASSET_POOL_TYPE_CONTRACT = "contract"
+	CONTRACT_ASSET_POOL_ADDR = "contractAssetPool"
+)
+
+const (
+	LISTING_STATUS_OPEN      = "OPEN"
+	LISTING_STATUS_SOLD      = "SOLD"
+	LISTING_STATUS_CANCELLED = "CANCELLED"
+)
+
+//背书节点接受的交易时间戳与本地时钟的最大偏差（秒）
+const MAX_TX_TIME_SKEW = 300
+
+const (
+	HTLC_STATUS_LOCKED   = "LOCKED"
+	HTLC_STATUS_CLAIMED  = "CLAIMED"
+	HTLC_STATUS_REFUNDED = "REFUNDED"
+)
+
+const (
+	ORG_STATUS_ENABLED  = "ENABLED"
+	ORG_STATUS_DISABLED = "DISABLED"
 )
 
 const (
@@ -26,4 +56,13 @@ const (
 	TX_TYPE_TRANSFER_IN  = "INCOME"
 	TX_TYPE_ISSUE        = "ISSUE"
 	TX_TYPE_TRANSFER     = "TRANSFER"
+	TX_TYPE_APPROVE      = "APPROVE"
+	TX_TYPE_REDEEM       = "REDEEM"
+)
+
+const (
+	SELECT_STRATEGY_SMALLEST_FIRST = "SMALLEST_FIRST"
+	SELECT_STRATEGY_LARGEST_FIRST  = "LARGEST_FIRST"
+	SELECT_STRATEGY_EXACT_MATCH    = "EXACT_MATCH"
+	SELECT_STRATEGY_MIN_CHANGE     = "MIN_CHANGE"
 )
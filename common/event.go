@@ -0,0 +1,73 @@
+package common
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+const (
+	EVENT_NAME    = "FabricTransaction"
+	EVENT_VERSION = "1.0"
+)
+
+//事件中只包含资产池ID，不包含资产地址
+type TxEvent struct {
+	TxType      string `json:"txType"`
+	AssetTypeID string `json:"assetTypeId"`
+	FromPool    string `json:"fromPool,omitempty"`
+	ToPool      string `json:"toPool,omitempty"`
+	Amount      Amount `json:"amount"`
+}
+
+type TxEventPayload struct {
+	Version string    `json:"version"`
+	TxID    string    `json:"txId"`
+	Events  []TxEvent `json:"events"`
+}
+
+//Fabric每个交易只保留最后一次SetEvent，EventStub收集一次调用中产生的全部事件，由Flush统一发出
+type EventStub struct {
+	shim.ChaincodeStubInterface
+	events []TxEvent
+}
+
+func NewEventStub(stub shim.ChaincodeStubInterface) *EventStub {
+	return &EventStub{ChaincodeStubInterface: stub}
+}
+
+//本次调用中已产生的交易记录
+func (es *EventStub) Events() []TxEvent {
+	return es.events
+}
+
+func (es *EventStub) Flush() error {
+	if len(es.events) == 0 {
+		return nil
+	}
+	return setTxEvents(es.ChaincodeStubInterface, es.events)
+}
+
+func SetTxEvent(stub shim.ChaincodeStubInterface, event TxEvent) error {
+	switch s := stub.(type) {
+	case *EventStub:
+		s.events = append(s.events, event)
+		return nil
+	case *ScopedTransientStub:
+		return SetTxEvent(s.ChaincodeStubInterface, event)
+	}
+	return setTxEvents(stub, []TxEvent{event})
+}
+
+func setTxEvents(stub shim.ChaincodeStubInterface, events []TxEvent) error {
+	payload := TxEventPayload{
+		Version: EVENT_VERSION,
+		TxID:    stub.GetTxID(),
+		Events:  events,
+	}
+	bytes, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return stub.SetEvent(EVENT_NAME, bytes)
+}
@@ -0,0 +1,82 @@
+package securityTool
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+//请求签名字段名
+const (
+	SIGN_FIELD       = "sign"
+	MULTI_SIGN_FIELD = "signs" //多方签名请求中各资产池的签名
+)
+
+//规范化JSON：对象键按UTF-8字节序排列，无空白，数字保持原文，不做HTML转义
+//excludeFields为需要从顶层对象中去掉的字段，如sign
+func CanonicalizeJSON(data []byte, excludeFields ...string) ([]byte, error) {
+	obj, err := decodeJSONObject(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range excludeFields {
+		delete(obj, v)
+	}
+	return marshalCanonical(obj)
+}
+
+//拆分签名请求，返回sign字段及去掉sign后的规范化内容
+func SplitSignedJSON(data []byte) (string, []byte, error) {
+	obj, err := decodeJSONObject(data)
+	if err != nil {
+		return "", nil, err
+	}
+	sign, ok := obj[SIGN_FIELD].(string)
+	if !ok || sign == "" {
+		return "", nil, errors.New("对象签名属性名[sign]无效")
+	}
+	delete(obj, SIGN_FIELD)
+	canonical, err := marshalCanonical(obj)
+	if err != nil {
+		return "", nil, err
+	}
+	return sign, canonical, nil
+}
+
+//为规范化后的请求加上sign字段
+func AppendSignToJSON(data []byte, sign string) ([]byte, error) {
+	obj, err := decodeJSONObject(data)
+	if err != nil {
+		return nil, err
+	}
+	obj[SIGN_FIELD] = sign
+	return marshalCanonical(obj)
+}
+
+func decodeJSONObject(data []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var obj map[string]interface{}
+	if err := decoder.Decode(&obj); err != nil {
+		return nil, err
+	}
+	if obj == nil {
+		return nil, errors.New("request is not a JSON object")
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after JSON object")
+	}
+	return obj, nil
+}
+
+//encoding/json对map按键排序输出，json.Number原样输出
+func marshalCanonical(v interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
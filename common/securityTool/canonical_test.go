@@ -0,0 +1,70 @@
+package securityTool
+
+import (
+	"testing"
+)
+
+//规范化JSON的标准向量，其他语言的客户端实现应得到相同结果
+var canonicalVectors = []struct {
+	input string
+	want  string
+}{
+	{`{"b":1,"a":2}`, `{"a":2,"b":1}`},
+	{"{ \"toPool\" : \"poolB\",\n  \"fromPool\" : \"poolA\" }", `{"fromPool":"poolA","toPool":"poolB"}`},
+	{`{"sign":"abc","amount":"10"}`, `{"amount":"10"}`},
+	{`{"amount":10.50,"big":12345678901234567890}`, `{"amount":10.50,"big":12345678901234567890}`},
+	{`{"o":{"z":[3,{"y":1,"x":2}],"a":null}}`, `{"o":{"a":null,"z":[3,{"x":2,"y":1}]}}`},
+	{`{"s":"<a&b>\"q\"\\é"}`, `{"s":"<a&b>\"q\"\\é"}`},
+	{`{"B":1,"a":2,"_":3}`, `{"B":1,"_":3,"a":2}`},
+	{`{"nested":{"sign":"kept"}}`, `{"nested":{"sign":"kept"}}`},
+}
+
+func TestCanonicalizeJSON(t *testing.T) {
+	for _, v := range canonicalVectors {
+		got, err := CanonicalizeJSON([]byte(v.input), SIGN_FIELD)
+		if err != nil {
+			t.Errorf("CanonicalizeJSON(%s): %v", v.input, err)
+			continue
+		}
+		if string(got) != v.want {
+			t.Errorf("CanonicalizeJSON(%s) = %s, want %s", v.input, got, v.want)
+		}
+	}
+
+	for _, v := range []string{`[1,2]`, `null`, `{"a":1} {"b":2}`, `{"a":1`} {
+		if _, err := CanonicalizeJSON([]byte(v)); err == nil {
+			t.Errorf("CanonicalizeJSON(%s) should fail", v)
+		}
+	}
+}
+
+const (
+	goldenECPublicKey = "MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEcM/iO3YWIhozwUq+BNuK0J6PLwysDY0rav3MGC9H/3BJXiaS40jnmBwbfFutrC6EEVY20mcFg7iclYDT1Gm46A=="
+	//对`{"amount":"10.5","assetTypeId":"CNY","fromPool":"poolA","toPool":"poolB","txType":"TRANSFER"}`的签名
+	goldenECSign = "MEQCIExcClC8kACYEalwUu7/L571RlAZTe6WgQcpNtXT1W3FAiAlexIuwIQ+t5S75FmaE+TmC/QYcNyOX9I570/Hx6zuzg=="
+)
+
+func TestCheckJSONObjectSignatureString(t *testing.T) {
+	valid := []string{
+		`{"amount":"10.5","assetTypeId":"CNY","fromPool":"poolA","toPool":"poolB","txType":"TRANSFER","sign":"` + goldenECSign + `"}`,
+		`{"sign":"` + goldenECSign + `","txType":"TRANSFER","toPool":"poolB","fromPool":"poolA","assetTypeId":"CNY","amount":"10.5"}`,
+		"{\n  \"fromPool\": \"poolA\",\n  \"toPool\": \"poolB\",\n  \"amount\": \"10.5\",\n  \"assetTypeId\": \"CNY\",\n  \"txType\": \"TRANSFER\",\n  \"sign\": \"" + goldenECSign + "\"\n}",
+	}
+	for _, v := range valid {
+		ok, err := CheckJSONObjectSignatureString(ECTool{}, v, goldenECPublicKey)
+		if !ok || err != nil {
+			t.Errorf("verify %s failed: %v", v, err)
+		}
+	}
+
+	invalid := []string{
+		`{"amount":"11","assetTypeId":"CNY","fromPool":"poolA","toPool":"poolB","txType":"TRANSFER","sign":"` + goldenECSign + `"}`,
+		`{"amount":"10.5","assetTypeId":"CNY","fromPool":"poolA","toPool":"poolB","txType":"TRANSFER","memo":"x","sign":"` + goldenECSign + `"}`,
+		`{"amount":"10.5","assetTypeId":"CNY","fromPool":"poolA","toPool":"poolB","txType":"TRANSFER"}`,
+	}
+	for _, v := range invalid {
+		if ok, _ := CheckJSONObjectSignatureString(ECTool{}, v, goldenECPublicKey); ok {
+			t.Errorf("verify %s should fail", v)
+		}
+	}
+}
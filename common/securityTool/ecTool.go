@@ -1,17 +1,181 @@
 package securityTool
 
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"strings"
+)
+
+const eciesInfo = "FabricTransaction ECIES P-256"
+
+//ECDSA P-256密钥套件，与Fabric身份证书使用的曲线一致
 type ECTool struct {
 }
 
-func (ec *ECTool) ParsePublicKey(publicKey string) (interface{}, error) {
-	return nil, nil
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+//转换公钥，支持完整PEM或去掉头尾的base64 PKIX公钥
+func (ec ECTool) ParsePublicKey(publicKey string) (interface{}, error) {
+	if !strings.Contains(publicKey, "-----BEGIN") {
+		publicKey = "-----BEGIN PUBLIC KEY-----\n" + publicKey + "\n" + "-----END PUBLIC KEY-----"
+	}
+	block, _ := pem.Decode([]byte(publicKey))
+	if block == nil {
+		return nil, errors.New("公钥解析出错")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("not an ECDSA public key")
+	}
+	if key.Curve != elliptic.P256() {
+		return nil, errors.New("only P-256 curve is supported")
+	}
+	return key, nil
+}
+
+//ECIES：临时密钥ECDH协商，HKDF-SHA256派生AES-256-GCM密钥，临时私钥与nonce均取自random
+//密文格式：临时公钥(65字节) || nonce(12字节) || 密文，整体base64编码
+func (ec ECTool) EncryptByPoolPublicKey(random io.Reader, publicKey []byte, data []byte) (string, error) {
+	key, err := ECTool.ParsePublicKey(ECTool{}, string(publicKey))
+	if err != nil {
+		return "", err
+	}
+	pubKey := key.(*ecdsa.PublicKey)
+
+	//标准库生成密钥时不保证只从random读取，临时私钥直接由random派生
+	scalar, err := randScalar(random, pubKey.Curve.Params().N)
+	if err != nil {
+		return "", err
+	}
+	ephemeralX, ephemeralY := pubKey.Curve.ScalarBaseMult(scalar.Bytes())
+	ephemeralPub := elliptic.Marshal(pubKey.Curve, ephemeralX, ephemeralY)
+	sharedX, _ := pubKey.Curve.ScalarMult(pubKey.X, pubKey.Y, scalar.Bytes())
+
+	gcm, err := eciesGCM(sharedX, ephemeralPub)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(random, nonce); err != nil {
+		return "", err
+	}
+
+	out := append(ephemeralPub, nonce...)
+	out = gcm.Seal(out, nonce, data, nil)
+	return base64.StdEncoding.EncodeToString(out), nil
+}
+
+//签名只接受base64编码的ASN.1 DER格式，且S不能大于N/2（low-S），保证同一请求只有一种有效签名
+func (ec ECTool) VerifySignByPoolPublicKey(data []byte, signature, publicKey string) (bool, error) {
+	pub, err := ECTool.ParsePublicKey(ECTool{}, publicKey)
+	if err != nil {
+		return false, err
+	}
+	pubKey := pub.(*ecdsa.PublicKey)
+
+	sign, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false, err
+	}
+	sig := ecdsaSignature{}
+	if rest, err := asn1.Unmarshal(sign, &sig); err != nil || len(rest) != 0 {
+		return false, errors.New("invalid ECDSA signature")
+	}
+	if der, err := asn1.Marshal(sig); err != nil || !bytes.Equal(der, sign) {
+		return false, errors.New("ECDSA signature is not DER encoded")
+	}
+	if sig.R.Sign() <= 0 || sig.S.Sign() <= 0 {
+		return false, errors.New("invalid ECDSA signature")
+	}
+	if sig.S.Cmp(halfOrder(pubKey.Curve)) > 0 {
+		return false, errors.New("ECDSA signature is not low-S")
+	}
+
+	hashByte := sha256.Sum256(data)
+	if !ecdsa.Verify(pubKey, hashByte[:], sig.R, sig.S) {
+		return false, errors.New("ECDSA verification error")
+	}
+	return true, nil
 }
-func (ec *ECTool) EncryptByPoolPublicKey(publicKey []byte, data []byte) (string, error) {
-	return "", nil
+
+//将签名转为low-S并编码为ASN.1 DER，客户端签名后使用
+func EncodeECDSASignature(curve elliptic.Curve, r, s *big.Int) ([]byte, error) {
+	if s.Cmp(halfOrder(curve)) > 0 {
+		s = new(big.Int).Sub(curve.Params().N, s)
+	}
+	return asn1.Marshal(ecdsaSignature{R: r, S: s})
 }
-func (ec *ECTool) VerifySignStrByPoolPublicKey(data []byte, signature, publicKey string) (bool, error) {
-	return false, nil
+
+func halfOrder(curve elliptic.Curve) *big.Int {
+	return new(big.Int).Rsh(curve.Params().N, 1)
 }
-func (ec *ECTool) VerifyTxByPoolPublicKey(publicKey string, obj interface{}) (bool, error) {
-	return false, nil
+
+//客户端使用资产池私钥解密EncryptByPoolPublicKey的结果（已做base64解码）
+func DecryptByECPrivateKey(privateKey *ecdsa.PrivateKey, data []byte) ([]byte, error) {
+	if len(data) < 65 {
+		return nil, errors.New("invalid ECIES ciphertext")
+	}
+	ephemeralPub := data[:65]
+	x, y := elliptic.Unmarshal(elliptic.P256(), ephemeralPub)
+	if x == nil {
+		return nil, errors.New("invalid ephemeral public key")
+	}
+	sharedX, _ := privateKey.Curve.ScalarMult(x, y, privateKey.D.Bytes())
+
+	gcm, err := eciesGCM(sharedX, ephemeralPub)
+	if err != nil {
+		return nil, err
+	}
+	data = data[65:]
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("invalid ECIES ciphertext")
+	}
+	return gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+}
+
+func eciesGCM(sharedX *big.Int, ephemeralPub []byte) (cipher.AEAD, error) {
+	secret := make([]byte, 32)
+	xBytes := sharedX.Bytes()
+	copy(secret[len(secret)-len(xBytes):], xBytes)
+	block, err := aes.NewCipher(hkdfSHA256(secret, ephemeralPub, []byte(eciesInfo), 32))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+//RFC 5869
+func hkdfSHA256(secret, salt, info []byte, length int) []byte {
+	extractor := hmac.New(sha256.New, salt)
+	extractor.Write(secret)
+	prk := extractor.Sum(nil)
+
+	var out, prev []byte
+	for counter := byte(1); len(out) < length; counter++ {
+		expander := hmac.New(sha256.New, prk)
+		expander.Write(prev)
+		expander.Write(info)
+		expander.Write([]byte{counter})
+		prev = expander.Sum(nil)
+		out = append(out, prev...)
+	}
+	return out[:length]
 }
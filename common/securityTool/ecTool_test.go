@@ -0,0 +1,110 @@
+package securityTool
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"testing"
+)
+
+func newECKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubBytes, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, base64.StdEncoding.EncodeToString(pubBytes)
+}
+
+func TestECToolParsePublicKey(t *testing.T) {
+	key, publicKey := newECKey(t)
+	pubBytes, _ := base64.StdEncoding.DecodeString(publicKey)
+	fullPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubBytes}))
+
+	for _, v := range []string{publicKey, fullPEM} {
+		pub, err := ECTool{}.ParsePublicKey(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pub.(*ecdsa.PublicKey).X.Cmp(key.X) != 0 {
+			t.Error("parsed key mismatch")
+		}
+	}
+
+	algorithm, err := DetectKeyAlgorithm(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if algorithm != KEY_ALGORITHM_ECDSA {
+		t.Errorf("DetectKeyAlgorithm = %s, want %s", algorithm, KEY_ALGORITHM_ECDSA)
+	}
+}
+
+func TestECToolVerifySign(t *testing.T) {
+	key, publicKey := newECKey(t)
+	data := []byte(`{"fromPool":"poolA","amount":"10"}`)
+	hash := sha256.Sum256(data)
+
+	r, s, err := ecdsa.Sign(rand.Reader, key, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	lowS, err := EncodeECDSASignature(key.Curve, r, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, err := ECTool{}.VerifySignByPoolPublicKey(data, base64.StdEncoding.EncodeToString(lowS), publicKey)
+	if !ok || err != nil {
+		t.Errorf("verify failed: %v", err)
+	}
+
+	//同一签名的其他形式：high-S、64字节r||s
+	sig := ecdsaSignature{}
+	asn1.Unmarshal(lowS, &sig)
+	highS, _ := asn1.Marshal(ecdsaSignature{R: sig.R, S: new(big.Int).Sub(key.Curve.Params().N, sig.S)})
+	raw := make([]byte, 64)
+	copy(raw[32-len(sig.R.Bytes()):32], sig.R.Bytes())
+	copy(raw[64-len(sig.S.Bytes()):], sig.S.Bytes())
+	for name, v := range map[string][]byte{"high-S": highS, "raw": raw} {
+		if ok, _ := (ECTool{}).VerifySignByPoolPublicKey(data, base64.StdEncoding.EncodeToString(v), publicKey); ok {
+			t.Errorf("%s signature should not verify", name)
+		}
+	}
+
+	if ok, _ := (ECTool{}).VerifySignByPoolPublicKey([]byte("tampered"), base64.StdEncoding.EncodeToString(lowS), publicKey); ok {
+		t.Error("tampered data should not verify")
+	}
+}
+
+func TestECToolEncrypt(t *testing.T) {
+	key, publicKey := newECKey(t)
+	data := []byte("assetAddr-0001")
+
+	ct, err := ECTool{}.EncryptByPoolPublicKey(rand.Reader, []byte(publicKey), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctBytes, _ := base64.StdEncoding.DecodeString(ct)
+	pt, err := DecryptByECPrivateKey(key, ctBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(pt, data) {
+		t.Errorf("decrypted %q, want %q", pt, data)
+	}
+
+	other, _ := newECKey(t)
+	if _, err := DecryptByECPrivateKey(other, ctBytes); err == nil {
+		t.Error("decrypt with another key should fail")
+	}
+}
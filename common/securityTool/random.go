@@ -0,0 +1,57 @@
+package securityTool
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/big"
+)
+
+//由种子派生的确定性随机数流：HMAC-SHA256计数器模式
+//链码中的加密须在各背书节点得到相同结果，不能使用crypto/rand
+type deterministicReader struct {
+	prk     []byte
+	counter uint32
+	buf     []byte
+}
+
+func NewDeterministicReader(seed []byte, info string) io.Reader {
+	extractor := hmac.New(sha256.New, seed)
+	extractor.Write([]byte(info))
+	return &deterministicReader{prk: extractor.Sum(nil)}
+}
+
+func (r *deterministicReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(r.buf) == 0 {
+			if r.counter == ^uint32(0) {
+				return n, errors.New("deterministic reader exhausted")
+			}
+			r.counter++
+			block := make([]byte, 4)
+			binary.BigEndian.PutUint32(block, r.counter)
+			expander := hmac.New(sha256.New, r.prk)
+			expander.Write(block)
+			r.buf = expander.Sum(nil)
+		}
+		copied := copy(p[n:], r.buf)
+		r.buf = r.buf[copied:]
+		n += copied
+	}
+	return n, nil
+}
+
+//从random读取[1, N-1]范围内的标量
+func randScalar(random io.Reader, n *big.Int) (*big.Int, error) {
+	bytes := make([]byte, (n.BitLen()+7)/8+8)
+	if _, err := io.ReadFull(random, bytes); err != nil {
+		return nil, err
+	}
+	nMinusOne := new(big.Int).Sub(n, big.NewInt(1))
+	k := new(big.Int).SetBytes(bytes)
+	k.Mod(k, nMinusOne)
+	return k.Add(k, big.NewInt(1)), nil
+}
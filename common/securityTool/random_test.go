@@ -0,0 +1,107 @@
+package securityTool
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"testing"
+)
+
+func TestDeterministicReader(t *testing.T) {
+	seed := []byte("0123456789abcdef0123456789abcdef")
+	read := func(seed []byte, info string) []byte {
+		out := make([]byte, 100)
+		r := NewDeterministicReader(seed, info)
+		//分多次读取与一次读取结果一致
+		r.Read(out[:7])
+		r.Read(out[7:])
+		return out
+	}
+	all := make([]byte, 100)
+	NewDeterministicReader(seed, "tx1/a").Read(all)
+	if !bytes.Equal(read(seed, "tx1/a"), all) {
+		t.Error("same seed and info should give the same stream")
+	}
+	if bytes.Equal(read(seed, "tx1/b"), all) || bytes.Equal(read([]byte("another seed"), "tx1/a"), all) {
+		t.Error("different seed or info should give a different stream")
+	}
+}
+
+//同一随机数流加密结果相同，且可用私钥解密
+func TestEncryptDeterministic(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPub, _ := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	ecKey, ecPublicKey := newECKey(t)
+
+	seed := []byte("0123456789abcdef0123456789abcdef")
+	data := []byte("assetAddr-0001")
+	cases := []struct {
+		tool      SecurityTool
+		publicKey string
+		decrypt   func([]byte) ([]byte, error)
+	}{
+		{RSATool{}, base64.StdEncoding.EncodeToString(rsaPub), func(ct []byte) ([]byte, error) {
+			return rsa.DecryptPKCS1v15(nil, rsaKey, ct)
+		}},
+		{ECTool{}, ecPublicKey, func(ct []byte) ([]byte, error) {
+			return DecryptByECPrivateKey(ecKey, ct)
+		}},
+	}
+	for _, c := range cases {
+		ct1, err := c.tool.EncryptByPoolPublicKey(NewDeterministicReader(seed, "tx1/a"), []byte(c.publicKey), data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ct2, _ := c.tool.EncryptByPoolPublicKey(NewDeterministicReader(seed, "tx1/a"), []byte(c.publicKey), data)
+		ct3, _ := c.tool.EncryptByPoolPublicKey(NewDeterministicReader(seed, "tx1/b"), []byte(c.publicKey), data)
+		if ct1 != ct2 {
+			t.Errorf("%T: same random stream gave different ciphertexts", c.tool)
+		}
+		if ct1 == ct3 {
+			t.Errorf("%T: different random streams gave the same ciphertext", c.tool)
+		}
+
+		ctBytes, _ := base64.StdEncoding.DecodeString(ct1)
+		pt, err := c.decrypt(ctBytes)
+		if err != nil {
+			t.Fatalf("%T: %v", c.tool, err)
+		}
+		if !bytes.Equal(pt, data) {
+			t.Errorf("%T: decrypted %q, want %q", c.tool, pt, data)
+		}
+	}
+}
+
+func TestSealDeterministic(t *testing.T) {
+	ecKey, publicKey := newECKey(t)
+	seed := []byte("0123456789abcdef0123456789abcdef")
+	data := []byte(`{"txId":"tx1","amount":"30"}`)
+
+	s1, err := SealByPoolPublicKey(ECTool{}, NewDeterministicReader(seed, "tx1/accountLog/0/OUT"), publicKey, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2, _ := SealByPoolPublicKey(ECTool{}, NewDeterministicReader(seed, "tx1/accountLog/0/OUT"), publicKey, data)
+	s3, _ := SealByPoolPublicKey(ECTool{}, NewDeterministicReader(seed, "tx1/accountLog/0/IN"), publicKey, data)
+	if *s1 != *s2 {
+		t.Error("same random stream gave different sealed data")
+	}
+	if s1.Ciphertext == s3.Ciphertext || s1.Nonce == s3.Nonce {
+		t.Error("different random streams gave the same sealed data")
+	}
+
+	pt, err := OpenSealedData(s1, func(encryptedKey []byte) ([]byte, error) {
+		return DecryptByECPrivateKey(ecKey, encryptedKey)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(pt, data) {
+		t.Errorf("opened %q, want %q", pt, data)
+	}
+}
@@ -2,13 +2,14 @@ package securityTool
 
 import (
 	"crypto"
-	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/pem"
 	"errors"
+	"io"
+	"math/big"
 )
 
 type RSATool struct {
@@ -25,17 +26,20 @@ func (rsaTool RSATool) ParsePublicKey(publicKey string) (interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
-	key := cert.(*rsa.PublicKey)
+	key, ok := cert.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("not an RSA public key")
+	}
 	return key, nil
 }
 
-func (rsaTool RSATool) EncryptByPoolPublicKey(publicKey []byte, data []byte) (string, error) {
+func (rsaTool RSATool) EncryptByPoolPublicKey(random io.Reader, publicKey []byte, data []byte) (string, error) {
 	key, err := RSATool.ParsePublicKey(RSATool{}, string(publicKey))
 	if err != nil {
 		return "", err
 	}
 	pubKey := key.(*rsa.PublicKey)
-	encryptedData, err := rsa.EncryptPKCS1v15(rand.Reader, pubKey, data)
+	encryptedData, err := encryptPKCS1v15(random, pubKey, data)
 	if err != nil {
 		return "", errors.New("encrypt failed:" + err.Error())
 	}
@@ -43,6 +47,37 @@ func (rsaTool RSATool) EncryptByPoolPublicKey(publicKey []byte, data []byte) (st
 	return dataStr, nil
 }
 
+//PKCS#1 v1.5加密，填充字节只取自random
+//标准库的rsa.EncryptPKCS1v15不保证使用传入的random，无法在各背书节点得到相同密文
+func encryptPKCS1v15(random io.Reader, pub *rsa.PublicKey, data []byte) ([]byte, error) {
+	k := (pub.N.BitLen() + 7) / 8
+	if len(data) > k-11 {
+		return nil, errors.New("data too long for RSA key size")
+	}
+
+	//0x00 || 0x02 || 非零填充 || 0x00 || data
+	em := make([]byte, k)
+	em[1] = 2
+	ps := em[2 : k-len(data)-1]
+	if _, err := io.ReadFull(random, ps); err != nil {
+		return nil, err
+	}
+	for i := range ps {
+		for ps[i] == 0 {
+			if _, err := io.ReadFull(random, ps[i:i+1]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	copy(em[k-len(data):], data)
+
+	c := new(big.Int).Exp(new(big.Int).SetBytes(em), big.NewInt(int64(pub.E)), pub.N)
+	out := make([]byte, k)
+	cBytes := c.Bytes()
+	copy(out[k-len(cBytes):], cBytes)
+	return out, nil
+}
+
 func (rsaTool RSATool) VerifySignByPoolPublicKey(data []byte, signature, publicKey string) (bool, error) {
 	//公钥
 	pub, err := RSATool.ParsePublicKey(RSATool{}, publicKey)
@@ -66,7 +101,3 @@ func (rsaTool RSATool) VerifySignByPoolPublicKey(data []byte, signature, publicK
 	}
 	return true, nil
 }
-
-func (rsaTool RSATool) VerifyTxByPoolPublicKey(publicKey string, obj interface{}) (bool, error) {
-	return false, nil
-}
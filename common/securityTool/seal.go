@@ -0,0 +1,83 @@
+package securityTool
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"errors"
+	"io"
+)
+
+//数字信封：数据使用随机AES-256-GCM密钥加密，AES密钥再使用资产池公钥加密
+//用于加密超出公钥算法单次加密长度的数据
+type SealedData struct {
+	EncryptedKey string `json:"encryptedKey"` //资产池公钥加密的AES密钥
+	Nonce        string `json:"nonce"`
+	Ciphertext   string `json:"ciphertext"`
+}
+
+//random提供AES密钥、nonce及公钥加密所用的随机数，链码中须使用由交易确定的随机源以保证各背书节点结果一致
+func SealByPoolPublicKey(tool SecurityTool, random io.Reader, publicKey string, data []byte) (*SealedData, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(random, key); err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(random, nonce); err != nil {
+		return nil, err
+	}
+
+	encryptedKey, err := tool.EncryptByPoolPublicKey(random, []byte(publicKey), key)
+	if err != nil {
+		return nil, err
+	}
+	return &SealedData{
+		EncryptedKey: encryptedKey,
+		Nonce:        base64.StdEncoding.EncodeToString(nonce),
+		Ciphertext:   base64.StdEncoding.EncodeToString(gcm.Seal(nil, nonce, data, nil)),
+	}, nil
+}
+
+//decryptKey使用资产池私钥解密EncryptedKey（已做base64解码）
+func OpenSealedData(sealed *SealedData, decryptKey func(encryptedKey []byte) ([]byte, error)) ([]byte, error) {
+	if sealed == nil {
+		return nil, errors.New("no sealed data")
+	}
+	encryptedKey, err := base64.StdEncoding.DecodeString(sealed.EncryptedKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := base64.StdEncoding.DecodeString(sealed.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(sealed.Ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := decryptKey(encryptedKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, errors.New("invalid nonce")
+	}
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -3,16 +3,54 @@ package securityTool
 import (
 	"crypto/sha256"
 	"encoding/base64"
-	"fmt"
-	"regexp"
-	"strings"
+	"errors"
+	"io"
 )
 
 type SecurityTool interface {
 	ParsePublicKey(publicKey string) (interface{}, error)
-	EncryptByPoolPublicKey(publicKey []byte, data []byte) (string, error)
+	//random为加密所需的随机数来源，链码中须使用由交易确定的随机数流，保证各背书节点结果一致
+	EncryptByPoolPublicKey(random io.Reader, publicKey []byte, data []byte) (string, error)
 	VerifySignByPoolPublicKey(data []byte, signature, publicKey string) (bool, error)
-	VerifyTxByPoolPublicKey(publicKey string, obj interface{}) (bool, error)
+}
+
+const (
+	KEY_ALGORITHM_RSA   = "RSA"
+	KEY_ALGORITHM_ECDSA = "ECDSA"
+)
+
+//密钥算法名到密钥套件的注册表
+var securityTools = map[string]SecurityTool{
+	KEY_ALGORITHM_RSA:   RSATool{},
+	KEY_ALGORITHM_ECDSA: ECTool{},
+}
+
+//按名称检测时的顺序，保证各节点结果一致
+var keyAlgorithms = []string{KEY_ALGORITHM_RSA, KEY_ALGORITHM_ECDSA}
+
+func RegisterSecurityTool(algorithm string, tool SecurityTool) {
+	if _, ok := securityTools[algorithm]; !ok {
+		keyAlgorithms = append(keyAlgorithms, algorithm)
+	}
+	securityTools[algorithm] = tool
+}
+
+func GetSecurityTool(algorithm string) (SecurityTool, error) {
+	tool, ok := securityTools[algorithm]
+	if !ok {
+		return nil, errors.New("unsupported key algorithm: " + algorithm)
+	}
+	return tool, nil
+}
+
+//根据公钥内容推断密钥算法，用于未指定keyAlgorithm的资产池
+func DetectKeyAlgorithm(publicKey string) (string, error) {
+	for _, v := range keyAlgorithms {
+		if _, err := securityTools[v].ParsePublicKey(publicKey); err == nil {
+			return v, nil
+		}
+	}
+	return "", errors.New("unsupported public key")
 }
 
 func CalcSHA256Base64Str(str string) (string, error) {
@@ -25,18 +63,11 @@ func CalcSHA256Base64Str(str string) (string, error) {
 	return base64.StdEncoding.EncodeToString(hashByte), nil
 }
 
-//验证签名，默认签名字段为ReqSign
-func CheckJSONObjectSignatureString(requestString, publicKey string) (bool, error) {
-	reg, _ := regexp.Compile(",\"sign\":\"(.+)\"")
-
-	match := reg.MatchString(requestString)
-	if match {
-		signString := reg.FindString(requestString)
-
-		requestString = strings.Replace(requestString, signString, "", -1)
-		signString = strings.TrimRight(signString, "\"")
-		signString = strings.TrimLeft(signString, ",\"sign\":\"")
-		return SecurityTool.VerifySignByPoolPublicKey(RSATool{}, []byte(requestString), signString, publicKey)
+//验证签名：去掉sign字段后按规范化JSON对请求内容验签
+func CheckJSONObjectSignatureString(tool SecurityTool, requestString, publicKey string) (bool, error) {
+	sign, canonical, err := SplitSignedJSON([]byte(requestString))
+	if err != nil {
+		return false, err
 	}
-	return false, fmt.Errorf("对象签名属性名[sign]无效")
+	return tool.VerifySignByPoolPublicKey(canonical, sign, publicKey)
 }
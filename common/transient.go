@@ -0,0 +1,63 @@
+package common
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/FabricTransaction/common/securityTool"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+//transient中加密随机种子的最小长度（字节）
+const MIN_ENCRYPT_SEED_LEN = 32
+
+//多段交易中每一段的transient视图：只可见以prefix开头的字段，并去掉前缀
+//如prefix为"leg0."时，transient中的"leg0.newAssetAddr"在该段中即为"newAssetAddr"
+type ScopedTransientStub struct {
+	shim.ChaincodeStubInterface
+	prefix string
+}
+
+func NewScopedTransientStub(stub shim.ChaincodeStubInterface, prefix string) *ScopedTransientStub {
+	return &ScopedTransientStub{ChaincodeStubInterface: stub, prefix: prefix}
+}
+
+func (s *ScopedTransientStub) GetTransient() (map[string][]byte, error) {
+	priData, err := s.ChaincodeStubInterface.GetTransient()
+	if err != nil {
+		return nil, err
+	}
+	scoped := map[string][]byte{}
+	for k, v := range priData {
+		if strings.HasPrefix(k, s.prefix) {
+			scoped[strings.TrimPrefix(k, s.prefix)] = v
+		}
+	}
+	return scoped, nil
+}
+
+//链码内加密使用的随机数流，由transient中的encryptSeed、交易ID与用途label派生
+//各背书节点得到相同的密文；种子只在transient中传递，旁观者无法由交易ID重算随机数
+//多段交易中各段共用顶层transient的种子，label须在交易内唯一，如以资产地址区分各输出
+func GetTxRand(stub shim.ChaincodeStubInterface, label string) (io.Reader, error) {
+	for {
+		scoped, ok := stub.(*ScopedTransientStub)
+		if !ok {
+			break
+		}
+		stub = scoped.ChaincodeStubInterface
+	}
+	priData, err := stub.GetTransient()
+	if err != nil {
+		return nil, err
+	}
+	seed, ok := priData["encryptSeed"]
+	if !ok {
+		return nil, errors.New("cannot get encryptSeed data")
+	}
+	if len(seed) < MIN_ENCRYPT_SEED_LEN {
+		return nil, errors.New("encryptSeed is too short")
+	}
+	return securityTool.NewDeterministicReader(seed, stub.GetTxID()+"/"+label), nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
@@ -23,6 +24,34 @@ func GetMspID(stub shim.ChaincodeStubInterface) (string, error) {
 	return mspId, nil
 }
 
+//交易时间戳，各背书节点一致
+func GetTxTime(stub shim.ChaincodeStubInterface) (time.Time, error) {
+	ts, err := stub.GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if ts == nil {
+		return time.Time{}, errors.New("no tx timestamp")
+	}
+	return time.Unix(ts.Seconds, int64(ts.Nanos)).UTC(), nil
+}
+
+//背书节点本地时钟，测试中可替换
+var EndorserNow = time.Now
+
+//交易时间戳由客户端设置，用于判断超时前须确认其与背书节点时钟相差不超过MAX_TX_TIME_SKEW秒
+func GetCheckedTxTime(stub shim.ChaincodeStubInterface) (time.Time, error) {
+	txTime, err := GetTxTime(stub)
+	if err != nil {
+		return txTime, err
+	}
+	skew := txTime.Sub(EndorserNow())
+	if skew > MAX_TX_TIME_SKEW*time.Second || skew < -MAX_TX_TIME_SKEW*time.Second {
+		return txTime, errors.New("tx timestamp " + txTime.Format(time.RFC3339) + " is too far from endorser clock")
+	}
+	return txTime, nil
+}
+
 func GetDataByKey(stub shim.ChaincodeStubInterface, objType string, addr []string, data interface{}) error {
 	exists, _, val, err := CheckExistByKey(stub, objType, addr)
 	if err != nil {
@@ -0,0 +1,137 @@
+package FabricTransaction
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/FabricTransaction/assetPool"
+	"github.com/FabricTransaction/common"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+type LockHTLCReq struct {
+	Sender      string        `json:"sender"`      //发送方资产池ID
+	Recipient   string        `json:"recipient"`   //接收方资产池ID
+	AssetTypeID string        `json:"assetTypeId"` //锁定资产类型
+	Amount      common.Amount `json:"amount"`      //锁定量
+	HashLock    string        `json:"hashLock"`    //原像的SHA-256，十六进制
+	Timeout     string        `json:"timeout"`     //超时时间，RFC3339格式
+	SignStruct
+}
+
+type ClaimHTLCReq struct {
+	Recipient string `json:"recipient"`
+	LockID    string `json:"lockId"`
+	Preimage  string `json:"preimage"` //原像，十六进制
+	SignStruct
+}
+
+type RefundHTLCReq struct {
+	Sender string `json:"sender"`
+	LockID string `json:"lockId"`
+	SignStruct
+}
+
+//transient: 发送方的assetAddrs、encryptedAddrs、blindings、changeAddr，以及托管资产地址newAssetAddr
+func lockHTLCHandler(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	req := LockHTLCReq{}
+	if err := json.Unmarshal([]byte(args[0]), &req); err != nil {
+		return nil, err
+	}
+
+	var sender, recipient assetPool.AssetPool
+	if err := common.GetDataByKey(stub, common.OBJECT_TYPE_ASEETPOOL, []string{req.Sender}, &sender); err != nil {
+		return nil, err
+	}
+	if err := common.GetDataByKey(stub, common.OBJECT_TYPE_ASEETPOOL, []string{req.Recipient}, &recipient); err != nil {
+		return nil, err
+	}
+	cp, err := assetPool.GetContractAssetPool(stub)
+	if err != nil {
+		return nil, err
+	}
+	lock, err := cp.Lock(stub, sender, recipient, req.AssetTypeID, req.Amount, req.HashLock, req.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(lock)
+}
+
+//transient: 接收方收款地址newAssetAddr
+func claimHTLCHandler(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	req := ClaimHTLCReq{}
+	if err := json.Unmarshal([]byte(args[0]), &req); err != nil {
+		return nil, err
+	}
+
+	lock, err := assetPool.GetHashTimeLock(stub, req.LockID)
+	if err != nil {
+		return nil, err
+	}
+	var recipient assetPool.AssetPool
+	if err := common.GetDataByKey(stub, common.OBJECT_TYPE_ASEETPOOL, []string{req.Recipient}, &recipient); err != nil {
+		return nil, err
+	}
+	priData, err := stub.GetTransient()
+	if err != nil {
+		return nil, err
+	}
+	newAssetAddr, ok := priData["newAssetAddr"]
+	if !ok {
+		return nil, errors.New("cannot get newAssetAddr data")
+	}
+
+	cp, err := assetPool.GetContractAssetPool(stub)
+	if err != nil {
+		return nil, err
+	}
+	if err := cp.Claim(stub, lock, recipient, req.Preimage, string(newAssetAddr)); err != nil {
+		return nil, err
+	}
+	return json.Marshal(lock)
+}
+
+//transient: 退回资产地址newAssetAddr
+func refundHTLCHandler(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	req := RefundHTLCReq{}
+	if err := json.Unmarshal([]byte(args[0]), &req); err != nil {
+		return nil, err
+	}
+
+	lock, err := assetPool.GetHashTimeLock(stub, req.LockID)
+	if err != nil {
+		return nil, err
+	}
+	var sender assetPool.AssetPool
+	if err := common.GetDataByKey(stub, common.OBJECT_TYPE_ASEETPOOL, []string{req.Sender}, &sender); err != nil {
+		return nil, err
+	}
+	priData, err := stub.GetTransient()
+	if err != nil {
+		return nil, err
+	}
+	newAssetAddr, ok := priData["newAssetAddr"]
+	if !ok {
+		return nil, errors.New("cannot get newAssetAddr data")
+	}
+
+	cp, err := assetPool.GetContractAssetPool(stub)
+	if err != nil {
+		return nil, err
+	}
+	if err := cp.RefundLock(stub, lock, sender, string(newAssetAddr)); err != nil {
+		return nil, err
+	}
+	return json.Marshal(lock)
+}
+
+func queryHTLCHandler(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) < 1 {
+		return nil, errors.New("no lock id")
+	}
+	lock, err := assetPool.GetHashTimeLock(stub, args[0])
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(lock)
+}
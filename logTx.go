@@ -0,0 +1,37 @@
+package FabricTransaction
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/FabricTransaction/txLog"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+//args: assetPoolId, pageSize, bookmark
+func queryAccountLogHandler(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) < 3 {
+		return nil, errors.New("need assetPoolId, pageSize and bookmark")
+	}
+	pageSize, err := strconv.ParseInt(args[1], 10, 32)
+	if err != nil || pageSize <= 0 {
+		return nil, errors.New("invalid pageSize: " + args[1])
+	}
+	page, err := txLog.GetAccountLogs(stub, args[0], int32(pageSize), args[2])
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(page)
+}
+
+func queryChainLogHandler(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) < 1 {
+		return nil, errors.New("no tx id")
+	}
+	chainLog, err := txLog.GetChainLog(stub, args[0])
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(chainLog)
+}
@@ -0,0 +1,101 @@
+package FabricTransaction
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/FabricTransaction/client"
+	"github.com/FabricTransaction/common"
+	"github.com/FabricTransaction/txLog"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+//逐页下载资产池的账户流水
+func (s *testStub) accountLogs(t *testing.T, pool *testPool, pageSize string) ([]txLog.AccountLog, int) {
+	var logs []txLog.AccountLog
+	pages := 0
+	bookmark := ""
+	for {
+		res := s.invoke(pool.mspID, nil, "queryAccountLog", pool.addr, pageSize, bookmark)
+		if res.Status != shim.OK {
+			t.Fatal(res.Message)
+		}
+		var page txLog.AccountLogPage
+		if err := json.Unmarshal(res.Payload, &page); err != nil {
+			t.Fatal(err)
+		}
+		pages++
+		logs = append(logs, page.Logs...)
+		if page.Bookmark == "" {
+			return logs, pages
+		}
+		bookmark = page.Bookmark
+	}
+}
+
+func TestQueryLogs(t *testing.T) {
+	stub := newTestStub(t)
+	poolA := stub.addPool(t, "Org1MSP", "poolA")
+	poolB := stub.addPool(t, "Org2MSP", "poolB")
+	poolC := stub.addPool(t, "Org2MSP", "poolC")
+	stub.issue(t, poolA, "CNY", "100", "a1")
+	if res := stub.transfer(t, poolA, poolB, "30", stub.transferTransient(t, poolA, []string{"a1"}, "b1", "a2")); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	transferTx := "tx" + strconv.Itoa(stub.txCount)
+	if res := stub.transfer(t, poolB, poolA, "10", stub.transferTransient(t, poolB, []string{"b1"}, "a3", "b2")); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	logs, pages := stub.accountLogs(t, poolA, "2")
+	if len(logs) != 3 || pages != 2 {
+		t.Fatalf("poolA has %d logs in %d pages, want 3 in 2", len(logs), pages)
+	}
+	details, err := client.DecryptStatement(poolA.key, logs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []txLog.AccountLogDetail{
+		{TxType: common.TX_TYPE_TRANSFER_IN, Counterparty: "", Amount: common.NewAmountFromInt(100)},
+		{TxType: common.TX_TYPE_TRANSFER_OUT, Counterparty: poolB.addr, Amount: common.NewAmountFromInt(30)},
+		{TxType: common.TX_TYPE_TRANSFER_IN, Counterparty: poolB.addr, Amount: common.NewAmountFromInt(10)},
+	}
+	for i, v := range details {
+		if v.TxType != want[i].TxType || v.Counterparty != want[i].Counterparty || v.AssetTypeID != "CNY" || v.Amount.Cmp(want[i].Amount) != 0 {
+			t.Errorf("poolA detail %d = %+v, want %+v", i, v, want[i])
+		}
+	}
+	if details[1].TxID != transferTx {
+		t.Errorf("transfer detail tx = %s, want %s", details[1].TxID, transferTx)
+	}
+	//其他资产池的私钥无法解密
+	if _, err := client.DecryptStatement(poolB.key, logs); err == nil {
+		t.Error("poolB decrypted poolA's statement")
+	}
+
+	if logs, _ := stub.accountLogs(t, poolC, "10"); len(logs) != 0 {
+		t.Errorf("poolC has %d logs, want none", len(logs))
+	}
+	if res := stub.invoke("Org1MSP", nil, "queryAccountLog", poolA.addr, "0", ""); res.Status == shim.OK {
+		t.Error("pageSize 0 accepted")
+	}
+
+	res := stub.invoke("Org1MSP", nil, "queryChainLog", transferTx)
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	var chainLog txLog.ChainLog
+	if err := json.Unmarshal(res.Payload, &chainLog); err != nil {
+		t.Fatal(err)
+	}
+	if chainLog.TxID != transferTx || len(chainLog.Records) != 1 {
+		t.Fatalf("chain log = %+v", chainLog)
+	}
+	if record := chainLog.Records[0]; record.TxType != common.TX_TYPE_TRANSFER || record.FromPool != poolA.addr || record.ToPool != poolB.addr || record.Amount.String() != "30" {
+		t.Errorf("chain log record = %+v", record)
+	}
+	if res := stub.invoke("Org1MSP", nil, "queryChainLog", "missing"); res.Status == shim.OK {
+		t.Error("chain log of unknown tx returned")
+	}
+}
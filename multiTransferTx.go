@@ -0,0 +1,127 @@
+package FabricTransaction
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+
+	"github.com/FabricTransaction/asset"
+	"github.com/FabricTransaction/assetPool"
+	"github.com/FabricTransaction/common"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+//多段转账中的一段
+type TransferLeg struct {
+	FromPool    string        `json:"fromPool"`    //转出资产池ID
+	ToPool      string        `json:"toPool"`      //转入资产池ID
+	AssetTypeID string        `json:"assetTypeId"` //资产类型
+	Amount      common.Amount `json:"amount"`      //转让量
+}
+
+//多段转账，如券款对付：全部转出资产池签名，各段全部成功或全部失败
+type MultiTransferReq struct {
+	Legs []TransferLeg `json:"legs"`
+	MultiSignStruct
+}
+
+type legContext struct {
+	leg  TransferLeg
+	from assetPool.AssetPool
+	to   assetPool.AssetPool
+	stub shim.ChaincodeStubInterface
+}
+
+//第i段的transient字段以"leg<i>."为前缀，如leg0.inputs、leg0.newAssetAddr、leg0.changeAddr
+func legTransientPrefix(i int) string {
+	return "leg" + strconv.Itoa(i) + "."
+}
+
+func multiTransferHandler(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) < 1 {
+		return nil, errors.New("no request")
+	}
+	req := MultiTransferReq{}
+	if err := json.Unmarshal([]byte(args[0]), &req); err != nil {
+		return nil, err
+	}
+	if len(req.Legs) == 0 {
+		return nil, errors.New("no transfer legs")
+	}
+
+	signers := map[string]bool{}
+	for _, v := range req.Legs {
+		signers[v.FromPool] = true
+	}
+	var poolIDs []string
+	for k := range signers {
+		poolIDs = append(poolIDs, k)
+	}
+	sort.Strings(poolIDs)
+	if err := VerifyMultiSignedReq(stub, args[0], poolIDs); err != nil {
+		return nil, err
+	}
+
+	legs, err := validateLegs(stub, req.Legs)
+	if err != nil {
+		return nil, err
+	}
+	selections := make([]*assetPool.Selection, 0, len(legs))
+	for i, v := range legs {
+		selection, err := v.from.TransferWithSelection(v.stub, v.leg.AssetTypeID, v.to, v.leg.Amount)
+		if err != nil {
+			return nil, errors.New("leg " + strconv.Itoa(i) + ": " + err.Error())
+		}
+		selections = append(selections, selection)
+	}
+	return json.Marshal(selections)
+}
+
+//执行前校验全部转账段，各段的输入、新资产地址与找零地址一起校验
+func validateLegs(stub shim.ChaincodeStubInterface, legs []TransferLeg) ([]legContext, error) {
+	addrs := assetPool.NewTxAddrs()
+	var contexts []legContext
+
+	for i, v := range legs {
+		legName := "leg " + strconv.Itoa(i)
+		ctx := legContext{leg: v, stub: common.NewScopedTransientStub(stub, legTransientPrefix(i))}
+		if v.FromPool == v.ToPool {
+			return nil, errors.New(legName + ": fromPool and toPool are the same")
+		}
+		if err := common.GetDataByKey(stub, common.OBJECT_TYPE_ASEETPOOL, []string{v.FromPool}, &ctx.from); err != nil {
+			return nil, errors.New(legName + ": get asset pool " + v.FromPool + " failed: " + err.Error())
+		}
+		if err := common.GetDataByKey(stub, common.OBJECT_TYPE_ASEETPOOL, []string{v.ToPool}, &ctx.to); err != nil {
+			return nil, errors.New(legName + ": get asset pool " + v.ToPool + " failed: " + err.Error())
+		}
+		if err := ctx.to.CheckRecipient(); err != nil {
+			return nil, errors.New(legName + ": " + err.Error())
+		}
+		if err := asset.CheckAmount(stub, v.AssetTypeID, v.Amount); err != nil {
+			return nil, errors.New(legName + ": " + err.Error())
+		}
+
+		inputs, err := assetPool.GetSpendInputs(ctx.stub)
+		if err != nil {
+			return nil, errors.New(legName + ": " + err.Error())
+		}
+		priData, err := ctx.stub.GetTransient()
+		if err != nil {
+			return nil, err
+		}
+		newAssetAddr, ok := priData["newAssetAddr"]
+		if !ok {
+			return nil, errors.New(legName + ": cannot get newAssetAddr data")
+		}
+		changeAddr, err := assetPool.GetChangeAddr(ctx.stub)
+		if err != nil {
+			return nil, err
+		}
+		if err := addrs.Add(inputs, []string{string(newAssetAddr)}, changeAddr); err != nil {
+			return nil, errors.New(legName + ": " + err.Error())
+		}
+		contexts = append(contexts, ctx)
+	}
+	return contexts, nil
+}
@@ -0,0 +1,149 @@
+package orgManage
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/FabricTransaction/common"
+	"github.com/FabricTransaction/common/securityTool"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+type Org struct {
+	MspID     string `json:"mspId"`     //机构MSP ID
+	OrgName   string `json:"orgName"`   //机构名称
+	PublicKey string `json:"publicKey"` //验签公钥
+	IsAdmin   bool   `json:"isAdmin"`   //是否为管理员机构
+	Status    string `json:"status"`    //机构状态：启用/停用
+	LastNonce uint64 `json:"lastNonce"` //机构签名请求已使用的最大序号
+}
+
+func (org *Org) Init(stub shim.ChaincodeStubInterface, info Org) error {
+	org.MspID = info.MspID
+	org.OrgName = info.OrgName
+	org.PublicKey = info.PublicKey
+	org.IsAdmin = info.IsAdmin
+	org.Status = common.ORG_STATUS_ENABLED
+	org.LastNonce = 0
+
+	exist, _, _, err := common.CheckExistByKey(stub, common.OBJECT_TYPE_ORG, []string{org.MspID})
+	if err != nil {
+		return err
+	}
+	if exist {
+		return errors.New("org " + org.MspID + " already exists")
+	}
+
+	return org.Store(stub)
+}
+
+func (org *Org) Store(stub shim.ChaincodeStubInterface) error {
+	err := org.VerifyFields()
+	if err != nil {
+		return err
+	}
+
+	bytes, err := json.Marshal(org)
+	if err != nil {
+		return err
+	}
+	key, err := stub.CreateCompositeKey(common.OBJECT_TYPE_ORG, []string{org.MspID})
+	if err != nil {
+		return err
+	}
+
+	return stub.PutState(key, bytes)
+}
+
+func (org *Org) VerifyFields() error {
+	if common.IsEmptyStr(org.MspID) {
+		return errors.New("org's mspId is empty")
+	}
+	if common.IsEmptyStr(org.OrgName) {
+		return errors.New("org's name is empty")
+	}
+	if common.IsEmptyStr(org.PublicKey) {
+		return errors.New("org's publicKey is empty")
+	}
+	if _, err := securityTool.DetectKeyAlgorithm(org.PublicKey); err != nil {
+		return errors.New("org's publicKey is invalid: " + err.Error())
+	}
+	if org.Status != common.ORG_STATUS_ENABLED && org.Status != common.ORG_STATUS_DISABLED {
+		return errors.New("invalid org status: " + org.Status)
+	}
+	return nil
+}
+
+//校验请求由机构登记的验签公钥签名
+func (org *Org) VerifySign(reqStr string) error {
+	algorithm, err := securityTool.DetectKeyAlgorithm(org.PublicKey)
+	if err != nil {
+		return err
+	}
+	tool, err := securityTool.GetSecurityTool(algorithm)
+	if err != nil {
+		return err
+	}
+	valid, err := securityTool.CheckJSONObjectSignatureString(tool, reqStr, org.PublicKey)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return errors.New("verify sign of org " + org.MspID + " failed")
+	}
+	return nil
+}
+
+//请求序号须大于机构已使用的序号，由调用方随机构信息一并保存
+func (org *Org) UseNonce(nonce uint64) error {
+	if nonce <= org.LastNonce {
+		return errors.New("stale nonce " + strconv.FormatUint(nonce, 10) + " for org " + org.MspID + ", last used is " + strconv.FormatUint(org.LastNonce, 10))
+	}
+	org.LastNonce = nonce
+	return nil
+}
+
+func (org *Org) IsEnabled() bool {
+	return org.Status == common.ORG_STATUS_ENABLED
+}
+
+func (org *Org) Disable(stub shim.ChaincodeStubInterface) error {
+	org.Status = common.ORG_STATUS_DISABLED
+	return org.Store(stub)
+}
+
+func GetOrg(stub shim.ChaincodeStubInterface, mspID string) (*Org, error) {
+	org := &Org{}
+	if err := common.GetDataByKey(stub, common.OBJECT_TYPE_ORG, []string{mspID}, org); err != nil {
+		return nil, errors.New("get org " + mspID + " failed: " + err.Error())
+	}
+	return org, nil
+}
+
+//获取调用者所属机构，机构需已登记且处于启用状态
+func GetInvokerOrg(stub shim.ChaincodeStubInterface) (*Org, error) {
+	mspID, err := common.GetMspID(stub)
+	if err != nil {
+		return nil, err
+	}
+	org, err := GetOrg(stub, mspID)
+	if err != nil {
+		return nil, err
+	}
+	if !org.IsEnabled() {
+		return nil, errors.New("org " + mspID + " is disabled")
+	}
+	return org, nil
+}
+
+func CheckAdminOrg(stub shim.ChaincodeStubInterface) error {
+	org, err := GetInvokerOrg(stub)
+	if err != nil {
+		return err
+	}
+	if !org.IsAdmin {
+		return errors.New(org.MspID + " is not an admin org")
+	}
+	return nil
+}
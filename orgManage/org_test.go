@@ -0,0 +1,45 @@
+package orgManage
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"testing"
+
+	"github.com/FabricTransaction/common"
+)
+
+func TestVerifyFieldsPublicKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubBytes, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	org := Org{MspID: "Org1MSP", OrgName: "org1", PublicKey: base64.StdEncoding.EncodeToString(pubBytes), Status: common.ORG_STATUS_ENABLED}
+	if err := org.VerifyFields(); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []string{"", "org1Key", base64.StdEncoding.EncodeToString([]byte("not a key"))} {
+		org.PublicKey = v
+		if err := org.VerifyFields(); err == nil {
+			t.Errorf("public key %q accepted", v)
+		}
+	}
+}
+
+func TestUseNonce(t *testing.T) {
+	org := Org{MspID: "Org1MSP"}
+	if err := org.UseNonce(0); err == nil {
+		t.Error("nonce 0 accepted")
+	}
+	if err := org.UseNonce(3); err != nil || org.LastNonce != 3 {
+		t.Fatalf("UseNonce(3) = %v, lastNonce %d", err, org.LastNonce)
+	}
+	for _, v := range []uint64{2, 3} {
+		if err := org.UseNonce(v); err == nil {
+			t.Errorf("nonce %d accepted after 3", v)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package FabricTransaction
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/FabricTransaction/common"
+	"github.com/FabricTransaction/orgManage"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func addOrgHandler(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) < 1 {
+		return nil, errors.New("no org")
+	}
+	info := orgManage.Org{}
+	if err := json.Unmarshal([]byte(args[0]), &info); err != nil {
+		return nil, err
+	}
+	return nil, new(orgManage.Org).Init(stub, info)
+}
+
+//普通机构修改自己的信息须使用当前登记的验签公钥签名
+type UpdateOrgReq struct {
+	orgManage.Org
+	SignStruct
+}
+
+//管理员机构可修改任意机构信息，普通机构只能修改自己的名称与验签公钥
+func updateOrgHandler(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) < 1 {
+		return nil, errors.New("no org")
+	}
+	req := UpdateOrgReq{}
+	if err := json.Unmarshal([]byte(args[0]), &req); err != nil {
+		return nil, err
+	}
+	info := req.Org
+
+	invoker, err := orgManage.GetInvokerOrg(stub)
+	if err != nil {
+		return nil, err
+	}
+	org, err := orgManage.GetOrg(stub, info.MspID)
+	if err != nil {
+		return nil, err
+	}
+	if !invoker.IsAdmin && invoker.MspID != org.MspID {
+		return nil, errors.New(invoker.MspID + " cannot update org " + org.MspID)
+	}
+	//管理员机构的修改由其MSP身份认证，可用于替换丢失或泄露的验签公钥
+	if !invoker.IsAdmin {
+		if err := checkSignedFunction(args[0], "updateOrg"); err != nil {
+			return nil, err
+		}
+		if err := org.VerifySign(args[0]); err != nil {
+			return nil, err
+		}
+		if err := checkExpireTime(stub, req.ExpireTime); err != nil {
+			return nil, err
+		}
+		if err := org.UseNonce(req.Nonce); err != nil {
+			return nil, err
+		}
+	}
+
+	org.OrgName = info.OrgName
+	org.PublicKey = info.PublicKey
+	if invoker.IsAdmin {
+		org.IsAdmin = info.IsAdmin
+		if !common.IsEmptyStr(info.Status) {
+			org.Status = info.Status
+		}
+		if invoker.MspID == org.MspID && (!org.IsAdmin || !org.IsEnabled()) {
+			return nil, errors.New("admin org cannot revoke or disable itself")
+		}
+	}
+	return nil, org.Store(stub)
+}
+
+func disableOrgHandler(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) < 1 {
+		return nil, errors.New("no org mspId")
+	}
+	mspID, err := common.GetMspID(stub)
+	if err != nil {
+		return nil, err
+	}
+	if mspID == args[0] {
+		return nil, errors.New("admin org cannot disable itself")
+	}
+	org, err := orgManage.GetOrg(stub, args[0])
+	if err != nil {
+		return nil, err
+	}
+	return nil, org.Disable(stub)
+}
+
+func queryOrgHandler(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) < 1 {
+		return nil, errors.New("no org mspId")
+	}
+	org, err := orgManage.GetOrg(stub, args[0])
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(org)
+}
@@ -0,0 +1,184 @@
+package FabricTransaction
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"testing"
+
+	"github.com/FabricTransaction/client"
+	"github.com/FabricTransaction/common"
+	"github.com/FabricTransaction/orgManage"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func (s *testStub) queryOrg(t *testing.T, mspID string) orgManage.Org {
+	res := s.invoke("Org1MSP", nil, "queryOrg", mspID)
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	var org orgManage.Org
+	json.Unmarshal(res.Payload, &org)
+	return org
+}
+
+func orgJSON(org orgManage.Org) string {
+	bytes, _ := json.Marshal(org)
+	return string(bytes)
+}
+
+//机构用key对修改请求签名
+func signOrgUpdate(t *testing.T, key *ecdsa.PrivateKey, org orgManage.Org, nonce uint64) string {
+	req := UpdateOrgReq{Org: org}
+	req.Function = "updateOrg"
+	req.Nonce = nonce
+	req.ExpireTime = testExpireTime
+	signed, err := client.SignRequest(key, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestOrgAdminRules(t *testing.T) {
+	stub := newTestStub(t)
+	org3 := orgManage.Org{MspID: "Org3MSP", OrgName: "org3", PublicKey: encodePublicKey(testOrgKey("Org3MSP"))}
+
+	invalid := org3
+	invalid.PublicKey = "org3Key"
+	if res := stub.invoke("Org1MSP", nil, "addOrg", orgJSON(invalid)); res.Status == shim.OK {
+		t.Error("org with invalid public key accepted")
+	}
+	if res := stub.invoke("Org1MSP", nil, "addOrg", orgJSON(org3)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := stub.invoke("Org1MSP", nil, "addOrg", orgJSON(org3)); res.Status == shim.OK {
+		t.Error("duplicate org accepted")
+	}
+
+	//普通机构不能新增机构、停用机构或修改其他机构
+	org4 := orgManage.Org{MspID: "Org4MSP", OrgName: "org4", PublicKey: encodePublicKey(testOrgKey("Org4MSP"))}
+	if res := stub.invoke("Org3MSP", nil, "addOrg", orgJSON(org4)); res.Status == shim.OK {
+		t.Error("non-admin org added an org")
+	}
+	if res := stub.invoke("Org3MSP", nil, "disableOrg", "Org2MSP"); res.Status == shim.OK {
+		t.Error("non-admin org disabled an org")
+	}
+	other := orgManage.Org{MspID: "Org2MSP", OrgName: "renamed", PublicKey: encodePublicKey(testOrgKey("Org2MSP"))}
+	if res := stub.invoke("Org3MSP", nil, "updateOrg", signOrgUpdate(t, testOrgKey("Org3MSP"), other, 1)); res.Status == shim.OK {
+		t.Error("non-admin org updated another org")
+	}
+
+	//管理员机构不能停用或撤销自己
+	if res := stub.invoke("Org1MSP", nil, "disableOrg", "Org1MSP"); res.Status == shim.OK {
+		t.Error("admin org disabled itself")
+	}
+	self := stub.queryOrg(t, "Org1MSP")
+	self.IsAdmin = false
+	if res := stub.invoke("Org1MSP", nil, "updateOrg", orgJSON(self)); res.Status == shim.OK {
+		t.Error("admin org revoked itself")
+	}
+
+	//停用的机构不能再提交请求
+	if res := stub.invoke("Org1MSP", nil, "disableOrg", "Org2MSP"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := stub.invoke("Org2MSP", nil, "disableOrg", "Org3MSP"); res.Status == shim.OK {
+		t.Error("disabled admin org disabled an org")
+	}
+	if org2 := stub.queryOrg(t, "Org2MSP"); org2.IsEnabled() {
+		t.Error("Org2MSP still enabled")
+	}
+}
+
+//普通机构修改自己的信息须由当前登记的验签公钥签名
+func TestUpdateOrgSigned(t *testing.T) {
+	stub := newTestStub(t)
+	oldKey := testOrgKey("Org3MSP")
+	org3 := orgManage.Org{MspID: "Org3MSP", OrgName: "org3", PublicKey: encodePublicKey(oldKey)}
+	if res := stub.invoke("Org1MSP", nil, "addOrg", orgJSON(org3)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	newKey := testOrgKey("Org3MSP-rotated")
+	update := org3
+	update.OrgName = "org3 renamed"
+	update.PublicKey = encodePublicKey(newKey)
+	update.IsAdmin = true
+	if res := stub.invoke("Org3MSP", nil, "updateOrg", orgJSON(update)); res.Status == shim.OK {
+		t.Error("unsigned self update accepted")
+	}
+	if res := stub.invoke("Org3MSP", nil, "updateOrg", signOrgUpdate(t, newKey, update, 1)); res.Status == shim.OK {
+		t.Error("self update signed by the new key accepted")
+	}
+	signed := signOrgUpdate(t, oldKey, update, 1)
+	if res := stub.invoke("Org3MSP", nil, "updateOrg", signed); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	got := stub.queryOrg(t, "Org3MSP")
+	if got.OrgName != "org3 renamed" || got.PublicKey != update.PublicKey || got.IsAdmin || got.LastNonce != 1 {
+		t.Errorf("org3 = %+v", got)
+	}
+
+	//轮换后旧密钥签名的请求和重放的请求均被拒绝
+	if res := stub.invoke("Org3MSP", nil, "updateOrg", signed); res.Status == shim.OK {
+		t.Error("replayed self update accepted")
+	}
+	if res := stub.invoke("Org3MSP", nil, "updateOrg", signOrgUpdate(t, oldKey, update, 2)); res.Status == shim.OK {
+		t.Error("self update signed by the rotated key accepted")
+	}
+	if res := stub.invoke("Org3MSP", nil, "updateOrg", signOrgUpdate(t, newKey, update, 1)); res.Status == shim.OK {
+		t.Error("stale nonce accepted")
+	}
+	if res := stub.invoke("Org3MSP", nil, "updateOrg", signOrgUpdate(t, newKey, update, 2)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	//管理员机构可直接替换验签公钥
+	update.PublicKey = encodePublicKey(oldKey)
+	update.IsAdmin = false
+	if res := stub.invoke("Org1MSP", nil, "updateOrg", orgJSON(update)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if got := stub.queryOrg(t, "Org3MSP"); got.PublicKey != update.PublicKey || got.Status != common.ORG_STATUS_ENABLED {
+		t.Errorf("org3 = %+v", got)
+	}
+}
+
+//停用机构的资产池不能再发行、转账、授权或赎回
+func TestDisabledOrgPools(t *testing.T) {
+	stub := newTestStub(t)
+	poolA := stub.addPool(t, "Org1MSP", "poolA")
+	poolB := stub.addPool(t, "Org2MSP", "poolB")
+	stub.issue(t, poolB, "BOND", "10", "bond1")
+	if res := stub.invoke("Org1MSP", nil, "disableOrg", "Org2MSP"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+
+	issueTransient := map[string][]byte{"assetAddr": []byte("bond2")}
+	issueReq := &TransferReq{ToPool: poolB.addr, Amount: common.NewAmountFromInt(5), TxType: common.TX_TYPE_ISSUE, AssetTypeID: "BOND"}
+	if res := stub.invoke("Org2MSP", issueTransient, "issue", poolB.sign(t, issueReq, issueTransient)); res.Status == shim.OK {
+		t.Error("disabled org issued")
+	}
+
+	transient := stub.transferTransient(t, poolB, []string{"bond1"}, "a1", "bond3")
+	transferReq := &TransferReq{FromPool: poolB.addr, ToPool: poolA.addr, Amount: common.NewAmountFromInt(3), TxType: common.TX_TYPE_TRANSFER, AssetTypeID: "BOND"}
+	if res := stub.invoke("Org2MSP", transient, "transfer", poolB.sign(t, transferReq, transient)); res.Status == shim.OK {
+		t.Error("disabled org transferred")
+	}
+
+	approve := &ApproveReq{FromPool: poolB.addr, Spender: poolA.addr, AssetTypeID: "BOND", Amount: common.NewAmountFromInt(3)}
+	if res := stub.invoke("Org2MSP", nil, "approve", poolB.signReq(t, "approve", approve, &approve.SignStruct, nil)); res.Status == shim.OK {
+		t.Error("disabled org approved")
+	}
+
+	transient = stub.transferTransient(t, poolB, []string{"bond1"}, "", "bond3")
+	delete(transient, "newAssetAddr")
+	redeem := &RedeemReq{FromPool: poolB.addr, AssetTypeID: "BOND", Amount: common.NewAmountFromInt(3), TxType: common.TX_TYPE_REDEEM}
+	if res := stub.invoke("Org2MSP", transient, "redeem", poolB.signReq(t, "redeem", redeem, &redeem.SignStruct, transient)); res.Status == shim.OK {
+		t.Error("disabled org redeemed")
+	}
+
+	if err := stub.verifyOwnership(t, stub.queryAsset(t, "bond1"), poolB); err != nil {
+		t.Errorf("bond1 after rejected requests: %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package FabricTransaction
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/FabricTransaction/assetPool"
+	"github.com/FabricTransaction/common"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+type RedeemReq struct {
+	FromPool    string        `json:"fromPool"`    //赎回资产池ID
+	AssetTypeID string        `json:"assetTypeId"` //资产类型
+	Amount      common.Amount `json:"amount"`      //赎回量
+	TxType      string        `json:"txType"`      //交易类型，须为赎回
+	SignStruct
+}
+
+//transient: assetAddrs、encryptedAddrs、blindings、changeAddr
+func redeemHandler(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	req := RedeemReq{}
+	if err := json.Unmarshal([]byte(args[0]), &req); err != nil {
+		return nil, err
+	}
+	if req.TxType != common.TX_TYPE_REDEEM {
+		return nil, errors.New("txType mismatch: " + req.TxType)
+	}
+
+	var pool assetPool.AssetPool
+	if err := common.GetDataByKey(stub, common.OBJECT_TYPE_ASEETPOOL, []string{req.FromPool}, &pool); err != nil {
+		return nil, err
+	}
+	return nil, pool.Redeem(stub, req.AssetTypeID, req.Amount)
+}
+
+func queryRedemptionsHandler(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) < 1 {
+		return nil, errors.New("no asset type id")
+	}
+	redemptions, err := assetPool.GetRedemptionsByAssetType(stub, args[0])
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(redemptions)
+}
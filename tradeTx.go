@@ -0,0 +1,148 @@
+package FabricTransaction
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/FabricTransaction/assetPool"
+	"github.com/FabricTransaction/common"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+type ListReq struct {
+	Seller      string        `json:"seller"`      //卖方资产池ID
+	AssetTypeID string        `json:"assetTypeId"` //待售资产类型
+	Amount      common.Amount `json:"amount"`      //待售资产量，需已授权给合约资产池
+	PriceTypeID string        `json:"priceTypeId"` //计价资产类型
+	Price       common.Amount `json:"price"`       //总价
+	SignStruct
+}
+
+type BuyReq struct {
+	Buyer     string `json:"buyer"` //买方资产池ID
+	ListingID string `json:"listingId"`
+	SignStruct
+}
+
+type CancelListingReq struct {
+	Seller    string `json:"seller"`
+	ListingID string `json:"listingId"`
+	SignStruct
+}
+
+//transient: 卖方的assetAddrs、encryptedAddrs、blindings、changeAddr，以及托管资产地址newAssetAddr
+func listAssetHandler(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	req := ListReq{}
+	if err := json.Unmarshal([]byte(args[0]), &req); err != nil {
+		return nil, err
+	}
+
+	var seller assetPool.AssetPool
+	if err := common.GetDataByKey(stub, common.OBJECT_TYPE_ASEETPOOL, []string{req.Seller}, &seller); err != nil {
+		return nil, err
+	}
+	cp, err := assetPool.GetContractAssetPool(stub)
+	if err != nil {
+		return nil, err
+	}
+	listing, err := cp.Escrow(stub, seller, req.AssetTypeID, req.Amount, req.PriceTypeID, req.Price)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(listing)
+}
+
+//买方向卖方支付总价后获得托管资产
+//transient: 买方的assetAddrs、encryptedAddrs、blindings、changeAddr，卖方收款地址newAssetAddr，买方收货地址purchaseAssetAddr
+func buyListingHandler(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	req := BuyReq{}
+	if err := json.Unmarshal([]byte(args[0]), &req); err != nil {
+		return nil, err
+	}
+
+	listing, err := assetPool.GetListing(stub, req.ListingID)
+	if err != nil {
+		return nil, err
+	}
+	if listing.Seller == req.Buyer {
+		return nil, errors.New("seller cannot buy its own listing")
+	}
+	var buyer, seller assetPool.AssetPool
+	if err := common.GetDataByKey(stub, common.OBJECT_TYPE_ASEETPOOL, []string{req.Buyer}, &buyer); err != nil {
+		return nil, err
+	}
+	if err := common.GetDataByKey(stub, common.OBJECT_TYPE_ASEETPOOL, []string{listing.Seller}, &seller); err != nil {
+		return nil, err
+	}
+
+	priData, err := stub.GetTransient()
+	if err != nil {
+		return nil, err
+	}
+	purchaseAddr, ok := priData["purchaseAssetAddr"]
+	if !ok {
+		return nil, errors.New("cannot get purchaseAssetAddr data")
+	}
+
+	ok, err = buyer.Transfer(stub, listing.PriceTypeID, seller, listing.Price)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("pay for listing failed")
+	}
+
+	cp, err := assetPool.GetContractAssetPool(stub)
+	if err != nil {
+		return nil, err
+	}
+	if err := cp.Release(stub, listing, buyer, string(purchaseAddr)); err != nil {
+		return nil, err
+	}
+	return json.Marshal(listing)
+}
+
+//transient: 退回资产地址newAssetAddr
+func cancelListingHandler(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	req := CancelListingReq{}
+	if err := json.Unmarshal([]byte(args[0]), &req); err != nil {
+		return nil, err
+	}
+
+	listing, err := assetPool.GetListing(stub, req.ListingID)
+	if err != nil {
+		return nil, err
+	}
+	if listing.Seller != req.Seller {
+		return nil, errors.New("listing " + listing.ListingID + " does not belong to " + req.Seller)
+	}
+
+	priData, err := stub.GetTransient()
+	if err != nil {
+		return nil, err
+	}
+	refundAddr, ok := priData["newAssetAddr"]
+	if !ok {
+		return nil, errors.New("cannot get newAssetAddr data")
+	}
+
+	cp, err := assetPool.GetContractAssetPool(stub)
+	if err != nil {
+		return nil, err
+	}
+	if err := cp.Refund(stub, listing, string(refundAddr)); err != nil {
+		return nil, err
+	}
+	return json.Marshal(listing)
+}
+
+func queryListingHandler(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) < 1 {
+		return nil, errors.New("no listing id")
+	}
+	listing, err := assetPool.GetListing(stub, args[0])
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(listing)
+}
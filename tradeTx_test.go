@@ -0,0 +1,176 @@
+package FabricTransaction
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/FabricTransaction/assetPool"
+	"github.com/FabricTransaction/common"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+//卖方授权合约资产池后挂单，返回挂单ID
+func (s *testStub) listAsset(t *testing.T, seller *testPool, amount int64, price int64, inputs []string, escrowAddr string, changeAddr string) (string, pb.Response) {
+	approve := &ApproveReq{FromPool: seller.addr, Spender: common.CONTRACT_ASSET_POOL_ADDR, AssetTypeID: "CNY", Amount: common.NewAmountFromInt(amount)}
+	if res := s.invoke(seller.mspID, nil, "approve", seller.signReq(t, "approve", approve, &approve.SignStruct, nil)); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	transient := s.transferTransient(t, seller, inputs, escrowAddr, changeAddr)
+	req := &ListReq{Seller: seller.addr, AssetTypeID: "CNY", Amount: common.NewAmountFromInt(amount), PriceTypeID: "BOND", Price: common.NewAmountFromInt(price)}
+	res := s.invoke(seller.mspID, transient, "listAsset", seller.signReq(t, "listAsset", req, &req.SignStruct, transient))
+	return "tx" + strconv.Itoa(s.txCount), res
+}
+
+func (s *testStub) buyListing(t *testing.T, buyer *testPool, listingID string, inputs []string, payAddr string, changeAddr string, purchaseAddr string) pb.Response {
+	transient := s.transferTransient(t, buyer, inputs, payAddr, changeAddr)
+	transient["purchaseAssetAddr"] = []byte(purchaseAddr)
+	req := &BuyReq{Buyer: buyer.addr, ListingID: listingID}
+	return s.invoke(buyer.mspID, transient, "buyListing", buyer.signReq(t, "buyListing", req, &req.SignStruct, transient))
+}
+
+func (s *testStub) cancelListing(t *testing.T, seller *testPool, listingID string, refundAddr string) pb.Response {
+	transient := map[string][]byte{"newAssetAddr": []byte(refundAddr)}
+	req := &CancelListingReq{Seller: seller.addr, ListingID: listingID}
+	return s.invoke(seller.mspID, transient, "cancelListing", seller.signReq(t, "cancelListing", req, &req.SignStruct, transient))
+}
+
+func (s *testStub) queryListing(t *testing.T, listingID string) assetPool.Listing {
+	res := s.invoke("Org1MSP", nil, "queryListing", listingID)
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	var listing assetPool.Listing
+	if err := json.Unmarshal(res.Payload, &listing); err != nil {
+		t.Fatal(err)
+	}
+	return listing
+}
+
+func (s *testStub) checkAsset(t *testing.T, addr string, pool *testPool, value string) {
+	t.Helper()
+	a := s.queryAsset(t, addr)
+	if err := s.verifyOwnership(t, a, pool); err != nil {
+		t.Errorf("%s: %v", addr, err)
+	}
+	if a.Value.String() != value {
+		t.Errorf("%s value = %s, want %s", addr, a.Value, value)
+	}
+}
+
+//挂单资产托管于合约资产池，成交时一手交钱一手交货，撤单退回卖方
+func TestListingTrade(t *testing.T) {
+	stub := newTestStub(t)
+	poolA := stub.addPool(t, "Org1MSP", "poolA")
+	poolB := stub.addPool(t, "Org2MSP", "poolB")
+	stub.issue(t, poolA, "CNY", "100", "a1")
+	stub.issue(t, poolB, "BOND", "5", "bond1")
+
+	listingID, res := stub.listAsset(t, poolA, 40, 3, []string{"a1"}, "escrow1", "a2")
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	listing := stub.queryListing(t, listingID)
+	if listing.Status != common.LISTING_STATUS_OPEN || listing.Seller != poolA.addr || listing.AssetAddr != "escrow1" {
+		t.Errorf("listing = %+v", listing)
+	}
+	stub.checkAsset(t, "a2", poolA, "60")
+	if err := stub.verifyOwnership(t, stub.queryAsset(t, "escrow1"), poolA); err == nil {
+		t.Error("escrowed asset still owned by seller")
+	}
+	stub.checkAllowance(t, poolA, &testPool{addr: common.CONTRACT_ASSET_POOL_ADDR}, "0")
+
+	if res := stub.buyListing(t, poolA, listingID, []string{"a2"}, "a-pay", "a3", "a-cny"); res.Status == shim.OK {
+		t.Fatal("seller bought its own listing")
+	}
+	if res := stub.cancelListing(t, poolB, listingID, "b-refund"); res.Status == shim.OK {
+		t.Fatal("non-seller cancelled listing")
+	}
+
+	if res := stub.buyListing(t, poolB, listingID, []string{"bond1"}, "a-pay", "bond2", "b-cny"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	listing = stub.queryListing(t, listingID)
+	if listing.Status != common.LISTING_STATUS_SOLD || listing.Buyer != poolB.addr {
+		t.Errorf("sold listing = %+v", listing)
+	}
+	stub.checkAsset(t, "a-pay", poolA, "3")
+	stub.checkAsset(t, "b-cny", poolB, "40")
+	stub.checkAsset(t, "bond2", poolB, "2")
+
+	stub.issue(t, poolB, "BOND", "5", "bond3")
+	if res := stub.buyListing(t, poolB, listingID, []string{"bond2", "bond3"}, "a-pay2", "bond4", "b-cny2"); res.Status == shim.OK {
+		t.Fatal("sold listing bought again")
+	}
+	if res := stub.cancelListing(t, poolA, listingID, "a-refund"); res.Status == shim.OK {
+		t.Fatal("sold listing cancelled")
+	}
+
+	listingID, res = stub.listAsset(t, poolA, 10, 1, []string{"a2"}, "escrow2", "a3")
+	if res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := stub.cancelListing(t, poolA, listingID, "a4"); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if listing := stub.queryListing(t, listingID); listing.Status != common.LISTING_STATUS_CANCELLED {
+		t.Errorf("cancelled listing = %+v", listing)
+	}
+	stub.checkAsset(t, "a4", poolA, "10")
+	stub.checkAsset(t, "a3", poolA, "50")
+	//MockStub不回滚被拒绝的交易，使用新发行的资产付款
+	stub.issue(t, poolB, "BOND", "5", "bond5")
+	if res := stub.buyListing(t, poolB, listingID, []string{"bond5"}, "a-pay3", "bond6", "b-cny3"); res.Status == shim.OK {
+		t.Fatal("cancelled listing bought")
+	}
+}
+
+//未授权合约资产池时不能挂单
+func TestListingRequiresApproval(t *testing.T) {
+	stub := newTestStub(t)
+	poolA := stub.addPool(t, "Org1MSP", "poolA")
+	stub.issue(t, poolA, "CNY", "100", "a1")
+
+	transient := stub.transferTransient(t, poolA, []string{"a1"}, "escrow1", "a2")
+	req := &ListReq{Seller: poolA.addr, AssetTypeID: "CNY", Amount: common.NewAmountFromInt(40), PriceTypeID: "BOND", Price: common.NewAmountFromInt(3)}
+	if res := stub.invoke(poolA.mspID, transient, "listAsset", poolA.signReq(t, "listAsset", req, &req.SignStruct, transient)); res.Status == shim.OK {
+		t.Fatal("listing without approval accepted")
+	}
+	stub.checkAsset(t, "a1", poolA, "100")
+}
+
+//转账、批量转账、多段转账与transferFrom都不能以合约资产池为接收方
+func TestContractPoolNotRecipient(t *testing.T) {
+	stub := newTestStub(t)
+	poolA := stub.addPool(t, "Org1MSP", "poolA")
+	poolS := stub.addPool(t, "Org2MSP", "poolS")
+	contract := &testPool{addr: common.CONTRACT_ASSET_POOL_ADDR}
+	stub.issue(t, poolA, "CNY", "100", "a1")
+
+	if res := stub.transfer(t, poolA, contract, "10", stub.transferTransient(t, poolA, []string{"a1"}, "x1", "a2")); res.Status == shim.OK {
+		t.Error("transfer to contract pool accepted")
+	}
+
+	transient := stub.transferTransient(t, poolA, []string{"a1"}, "", "a2")
+	delete(transient, "newAssetAddr")
+	transient["newAssetAddrs"], _ = json.Marshal([]string{"x1"})
+	batch := &BatchTransferReq{FromPool: poolA.addr, AssetTypeID: "CNY", Payouts: []PayoutReq{{ToPool: contract.addr, Amount: common.NewAmountFromInt(10)}}}
+	if res := stub.invoke("Org1MSP", transient, "batchTransfer", poolA.signReq(t, "batchTransfer", batch, &batch.SignStruct, transient)); res.Status == shim.OK {
+		t.Error("batchTransfer to contract pool accepted")
+	}
+
+	legs := []TransferLeg{{FromPool: poolA.addr, ToPool: contract.addr, AssetTypeID: "CNY", Amount: common.NewAmountFromInt(10)}}
+	if res := stub.multiTransfer(t, legs, []map[string][]byte{stub.transferTransient(t, poolA, []string{"a1"}, "x1", "a2")}, poolA); res.Status == shim.OK {
+		t.Error("multiTransfer to contract pool accepted")
+	}
+
+	if res := stub.approve(t, poolA, poolS, 10); res.Status != shim.OK {
+		t.Fatal(res.Message)
+	}
+	if res := stub.transferFrom(t, poolS, poolA, contract, 10, stub.transferTransient(t, poolA, []string{"a1"}, "x1", "a2")); res.Status == shim.OK {
+		t.Error("transferFrom to contract pool accepted")
+	}
+
+	stub.checkAsset(t, "a1", poolA, "100")
+}
@@ -0,0 +1,187 @@
+package txLog
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/FabricTransaction/assetPool"
+	"github.com/FabricTransaction/common"
+	"github.com/FabricTransaction/common/securityTool"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+//资产池账户流水，每个资产池在一笔交易中的每次收支对应一条
+//明文中只有不透明的流水ID与资产池ID，流水内容使用资产池公钥加密
+//资产池ID与交易双方、金额同样出现在链码事件和链上流水中，加密并不隐藏资产池参与了哪些交易
+type AccountLog struct {
+	LogID         string                   `json:"logId"`
+	AssetPoolAddr string                   `json:"assetPoolAddr"`
+	Detail        *securityTool.SealedData `json:"detail"` //加密的AccountLogDetail
+}
+
+type AccountLogDetail struct {
+	TxID         string        `json:"txId"`
+	TxType       string        `json:"txType"`       //INCOME/OUTCOME
+	Counterparty string        `json:"counterparty"` //对方资产池ID，发行、赎回时为空
+	AssetTypeID  string        `json:"assetTypeId"`
+	Amount       common.Amount `json:"amount"`
+	TxTime       string        `json:"txTime"`
+}
+
+//链上流水，每笔交易一条，记录内容与链码事件相同
+type ChainLog struct {
+	LogID   string           `json:"logId"`
+	TxID    string           `json:"txId"`
+	TxTime  string           `json:"txTime"`
+	Records []common.TxEvent `json:"records"`
+}
+
+//流水按不透明ID排序，客户端解密后按TxTime排序
+type AccountLogPage struct {
+	Logs     []AccountLog `json:"logs"`
+	Bookmark string       `json:"bookmark"` //下一页起始位置，为空表示已无更多数据
+}
+
+//根据本次调用产生的交易记录写入链上流水及各资产池的账户流水
+func WriteTxLogs(stub shim.ChaincodeStubInterface, records []common.TxEvent) error {
+	if len(records) == 0 {
+		return nil
+	}
+	txTime, err := common.GetTxTime(stub)
+	if err != nil {
+		return err
+	}
+	txID := stub.GetTxID()
+
+	chainLog := ChainLog{
+		LogID:   common.CHAIN_LOG_PREFIX + txID,
+		TxID:    txID,
+		TxTime:  txTime.Format(time.RFC3339Nano),
+		Records: records,
+	}
+	if err := chainLog.Store(stub); err != nil {
+		return err
+	}
+
+	for i, v := range records {
+		if v.TxType == common.TX_TYPE_APPROVE {
+			continue
+		}
+		if !common.IsEmptyStr(v.FromPool) {
+			if err := storeAccountLog(stub, txTime, i, v.FromPool, common.TX_TYPE_TRANSFER_OUT, v.ToPool, v); err != nil {
+				return err
+			}
+		}
+		if !common.IsEmptyStr(v.ToPool) {
+			if err := storeAccountLog(stub, txTime, i, v.ToPool, common.TX_TYPE_TRANSFER_IN, v.FromPool, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+//index为交易记录下标，与收支方向一起确定本条流水的加密随机数
+func storeAccountLog(stub shim.ChaincodeStubInterface, txTime time.Time, index int, poolAddr string, direction string, counterparty string, record common.TxEvent) error {
+	var pool assetPool.AssetPool
+	if err := common.GetDataByKey(stub, common.OBJECT_TYPE_ASEETPOOL, []string{poolAddr}, &pool); err != nil {
+		return err
+	}
+	//合约资产池没有公钥，其托管记录本身即公开
+	if common.IsEmptyStr(pool.PublicKey) {
+		return nil
+	}
+
+	detail := AccountLogDetail{
+		TxID:         stub.GetTxID(),
+		TxType:       direction,
+		Counterparty: counterparty,
+		AssetTypeID:  record.AssetTypeID,
+		Amount:       record.Amount,
+		TxTime:       txTime.Format(time.RFC3339Nano),
+	}
+	detailBytes, err := json.Marshal(detail)
+	if err != nil {
+		return err
+	}
+	tool, err := pool.GetSecurityTool()
+	if err != nil {
+		return err
+	}
+	random, err := common.GetTxRand(stub, "accountLog/"+strconv.Itoa(index)+"/"+direction)
+	if err != nil {
+		return err
+	}
+	sealed, err := securityTool.SealByPoolPublicKey(tool, random, pool.PublicKey, detailBytes)
+	if err != nil {
+		return err
+	}
+	//流水ID由密文计算，无法由交易ID反推
+	logID, err := securityTool.CalcSHA256Base64Str(sealed.EncryptedKey + sealed.Ciphertext)
+	if err != nil {
+		return err
+	}
+	accountLog := AccountLog{
+		LogID:         common.ACCOUNT_LOG_PREFIX + logID,
+		AssetPoolAddr: poolAddr,
+		Detail:        sealed,
+	}
+
+	bytes, err := json.Marshal(accountLog)
+	if err != nil {
+		return err
+	}
+	key, err := stub.CreateCompositeKey(common.OBJECT_TYPE_LOG_ADDR, []string{accountLog.AssetPoolAddr, accountLog.LogID})
+	if err != nil {
+		return err
+	}
+	return stub.PutState(key, bytes)
+}
+
+func (chainLog *ChainLog) Store(stub shim.ChaincodeStubInterface) error {
+	bytes, err := json.Marshal(chainLog)
+	if err != nil {
+		return err
+	}
+	key, err := stub.CreateCompositeKey(common.OBJECT_TYPE_CHAIN_LOG, []string{chainLog.TxID})
+	if err != nil {
+		return err
+	}
+	return stub.PutState(key, bytes)
+}
+
+func GetChainLog(stub shim.ChaincodeStubInterface, txID string) (*ChainLog, error) {
+	chainLog := &ChainLog{}
+	if err := common.GetDataByKey(stub, common.OBJECT_TYPE_CHAIN_LOG, []string{txID}, chainLog); err != nil {
+		return nil, errors.New("get chain log " + txID + " failed: " + err.Error())
+	}
+	return chainLog, nil
+}
+
+//分页查询资产池的账户流水
+func GetAccountLogs(stub shim.ChaincodeStubInterface, poolAddr string, pageSize int32, bookmark string) (*AccountLogPage, error) {
+	iter, meta, err := stub.GetStateByPartialCompositeKeyWithPagination(common.OBJECT_TYPE_LOG_ADDR, []string{poolAddr}, pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	page := &AccountLogPage{Logs: []AccountLog{}}
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		var accountLog AccountLog
+		if err := json.Unmarshal(kv.Value, &accountLog); err != nil {
+			return nil, err
+		}
+		page.Logs = append(page.Logs, accountLog)
+	}
+	if meta != nil {
+		page.Bookmark = meta.Bookmark
+	}
+	return page, nil
+}